@@ -0,0 +1,121 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// An AnnotationStyle is a style of annotation of the sizes of containers.
+type AnnotationStyle string
+
+// Annotation styles.
+const (
+	AnnotationsNone    AnnotationStyle = ""        // No annotations.
+	AnnotationsLength  AnnotationStyle = "length"  // root.items.length = 3; after the elements of arrays.
+	AnnotationsComment AnnotationStyle = "comment" // root.items = []; /* 3 items */ on declarations.
+)
+
+var sizeCommentRegexp = regexp.MustCompile(`^/\*\s*(\d+) (items?|propert(?:y|ies))\s*\*/$`)
+
+// An annotation is a declared size of the array or object at properties.
+type annotation struct {
+	identifier string
+	properties []interface{}
+	array      bool
+	size       int
+}
+
+// sizeComment returns the comment that annotates a container with size
+// elements.
+func sizeComment(array bool, size int) string {
+	switch {
+	case array && size == 1:
+		return "/* 1 item */"
+	case array:
+		return "/* " + strconv.Itoa(size) + " items */"
+	case size == 1:
+		return "/* 1 property */"
+	default:
+		return "/* " + strconv.Itoa(size) + " properties */"
+	}
+}
+
+// isLengthAnnotation returns whether assignment, like `root.items.length = 3;`,
+// annotates the length of an array in root rather than setting a value.
+func isLengthAnnotation(root interface{}, assignment *assignment) bool {
+	n := len(assignment.properties)
+	if assignment.op != assignmentSet || n == 0 || assignment.properties[n-1] != "length" {
+		return false
+	}
+	_, ok := lookupValue(root, assignment.properties[:n-1]).([]interface{})
+	return ok
+}
+
+// parseAnnotation returns the annotation made by assignment, if any, given
+// root before assignment is applied.
+func parseAnnotation(root interface{}, assignment *assignment) (*annotation, error) {
+	if isLengthAnnotation(root, assignment) {
+		properties := assignment.properties[:len(assignment.properties)-1]
+		number, ok := assignment.value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid length", FormatPath(assignment.identifier, assignment.properties))
+		}
+		length, err := strconv.Atoi(number.String())
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("%s: invalid length", FormatPath(assignment.identifier, assignment.properties))
+		}
+		return &annotation{
+			identifier: assignment.identifier,
+			properties: properties,
+			array:      true,
+			size:       length,
+		}, nil
+	}
+	if assignment.op != assignmentSet {
+		return nil, nil
+	}
+	match := sizeCommentRegexp.FindStringSubmatch(assignment.comment)
+	if match == nil {
+		return nil, nil
+	}
+	size, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid size", FormatPath(assignment.identifier, assignment.properties))
+	}
+	return &annotation{
+		identifier: assignment.identifier,
+		properties: assignment.properties,
+		array:      match[2] == "item" || match[2] == "items",
+		size:       size,
+	}, nil
+}
+
+// check returns an error if the value at a's properties in root does not have
+// a's size.
+func (a *annotation) check(root interface{}) error {
+	path := FormatPath(a.identifier, a.properties)
+	value := lookupValue(root, a.properties)
+	size := -1
+	switch value := value.(type) {
+	case []interface{}:
+		if a.array {
+			size = len(value)
+		}
+	case map[string]interface{}:
+		if !a.array {
+			size = len(value)
+		}
+	}
+	switch {
+	case size == -1 && a.array:
+		return fmt.Errorf("%s: declared an array, found %s", path, typeName(value))
+	case size == -1:
+		return fmt.Errorf("%s: declared an object, found %s", path, typeName(value))
+	case size != a.size:
+		return fmt.Errorf("%s: declared size %d, found %d", path, a.size, size)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package flatjson
+
+import "sort"
+
+// A Deviation is a path at which one or more targets differ from a baseline.
+// Baseline is the value in the baseline, if HasBaseline is set. Changes has
+// one element for each target, which is the change from the baseline to the
+// target at Path, or the zero Change if the target matches the baseline.
+type Deviation struct {
+	Path        string
+	Baseline    interface{}
+	HasBaseline bool
+	Changes     []Change
+}
+
+// GroupDeviations groups changes by path. changes has one element for each
+// target, which are the changes from a baseline to the target, for example as
+// returned by DiffFlat. Moves are ignored. Deviations are returned in path
+// order.
+func GroupDeviations(changes [][]Change) ([]Deviation, error) {
+	type keyedDeviation struct {
+		key       pathKey
+		deviation *Deviation
+	}
+	var keyedDeviations []keyedDeviation
+	deviations := make(map[string]*Deviation)
+	for i, targetChanges := range changes {
+		for _, change := range targetChanges {
+			if change.Op == ChangeMove {
+				continue
+			}
+			deviation, ok := deviations[change.Path]
+			if !ok {
+				identifier, properties, err := ParsePath(change.Path)
+				if err != nil {
+					return nil, err
+				}
+				deviation = &Deviation{
+					Path:    change.Path,
+					Changes: make([]Change, len(changes)),
+				}
+				deviations[change.Path] = deviation
+				keyedDeviations = append(keyedDeviations, keyedDeviation{
+					key:       pathKey{identifier: identifier, properties: properties},
+					deviation: deviation,
+				})
+			}
+			if change.Op != ChangeAdd {
+				deviation.Baseline = change.Old
+				deviation.HasBaseline = true
+			}
+			deviation.Changes[i] = change
+		}
+	}
+	sort.Slice(keyedDeviations, func(i, j int) bool {
+		return comparePathKeys(&keyedDeviations[i].key, &keyedDeviations[j].key) < 0
+	})
+	result := make([]Deviation, 0, len(keyedDeviations))
+	for _, keyedDeviation := range keyedDeviations {
+		result = append(result, *keyedDeviation.deviation)
+	}
+	return result, nil
+}
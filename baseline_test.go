@@ -0,0 +1,62 @@
+package flatjson
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestGroupDeviations(t *testing.T) {
+	actual, err := GroupDeviations([][]Change{
+		{
+			{Op: ChangeReplace, Path: "root.b", Old: 1.0, New: 2.0},
+			{Op: ChangeRemove, Path: "root.items[10]", Old: "x"},
+		},
+		{},
+		{
+			{Op: ChangeMove, Path: "root.items[0]", From: "root.items[1]"},
+			{Op: ChangeAdd, Path: "root.a", New: true},
+			{Op: ChangeReplace, Path: "root.b", Old: 1.0, New: 3.0},
+			{Op: ChangeAdd, Path: "root.items[2]", New: "y"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Deviation{
+		{
+			Path: "root.a",
+			Changes: []Change{
+				{},
+				{},
+				{Op: ChangeAdd, Path: "root.a", New: true},
+			},
+		},
+		{
+			Path:        "root.b",
+			Baseline:    1.0,
+			HasBaseline: true,
+			Changes: []Change{
+				{Op: ChangeReplace, Path: "root.b", Old: 1.0, New: 2.0},
+				{},
+				{Op: ChangeReplace, Path: "root.b", Old: 1.0, New: 3.0},
+			},
+		},
+		{
+			Path: "root.items[2]",
+			Changes: []Change{
+				{},
+				{},
+				{Op: ChangeAdd, Path: "root.items[2]", New: "y"},
+			},
+		},
+		{
+			Path:        "root.items[10]",
+			Baseline:    "x",
+			HasBaseline: true,
+			Changes: []Change{
+				{Op: ChangeRemove, Path: "root.items[10]", Old: "x"},
+				{},
+				{},
+			},
+		},
+	}, actual)
+}
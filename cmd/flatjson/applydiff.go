@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/twpayne/flatjson"
+)
+
+// runApplyDiff applies the unified diff of flat JSON in the file named by the
+// second argument to the flat writes of the file named by the first argument
+// and writes the resulting JSON to stdout. If the second argument is omitted
+// then the diff is read from stdin. Nothing is written if any hunk conflicts.
+func runApplyDiff(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("apply-diff requires an original filename and an optional diff filename")
+	}
+	value, err := readValueFromFile(args[0])
+	if err != nil {
+		return err
+	}
+	sb := &strings.Builder{}
+	f := flatjson.NewFlattener(sb, flattenerOptions()...)
+	if err := f.WriteValue(value); err != nil {
+		return err
+	}
+	r := os.Stdin
+	if len(args) == 2 {
+		if r, err = os.Open(args[1]); err != nil {
+			return err
+		}
+		defer r.Close()
+	}
+	lines, conflicts, err := flatjson.ApplyUnifiedDiff(flatjson.SplitLines(sb.String()), r, *fuzz)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		errs := make([]error, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			errs = append(errs, fmt.Errorf("%s: %w", args[0], &conflict))
+		}
+		return errors.Join(errs...)
+	}
+	result, err := flatjson.UnmarshalInput([]byte(strings.Join(lines, "")), flatjson.InputFormatFlat)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(result)
+}
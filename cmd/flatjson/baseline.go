@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/twpayne/flatjson"
+)
+
+// runBaseline compares each file specified on the command line with the
+// baseline file, writes the paths at which they deviate, and returns whether
+// any file deviates.
+func runBaseline() (bool, error) {
+	if len(pflag.Args()) == 0 {
+		return false, errors.New("--baseline requires at least one filename")
+	}
+	baselineValue, err := readValueFromFile(*baseline)
+	if err != nil {
+		return false, err
+	}
+	differ, err := newDiffer()
+	if err != nil {
+		return false, err
+	}
+	changes := make([][]flatjson.Change, 0, pflag.NArg())
+	for _, arg := range pflag.Args() {
+		value, err := readValueFromFile(arg)
+		if err != nil {
+			return false, err
+		}
+		a := diffFile{name: *baseline, value: baselineValue, exists: true}
+		b := diffFile{name: arg, value: value, exists: true}
+		_, text, err := flattenDiffFiles(differ, a, b)
+		if err != nil {
+			return false, err
+		}
+		targetChanges, err := flatjson.DiffFlat(strings.NewReader(text[0]), strings.NewReader(text[1]))
+		if err != nil {
+			return false, err
+		}
+		changes = append(changes, targetChanges)
+	}
+	deviations, err := flatjson.GroupDeviations(changes)
+	if err != nil {
+		return false, err
+	}
+	differences := len(deviations) > 0
+	switch *format {
+	case "json":
+		return differences, writeDeviationsJSON(os.Stdout, deviations)
+	case "matrix":
+		return differences, writeDeviationsMatrix(os.Stdout, deviations)
+	case "unified":
+		return differences, writeDeviations(os.Stdout, deviations)
+	default:
+		return false, fmt.Errorf("--format=%s: not supported with --baseline", *format)
+	}
+}
+
+// deviationValue returns the value at deviation's path after change, or the
+// baseline value if change is the zero Change, as JSON.
+func deviationValue(deviation flatjson.Deviation, change flatjson.Change) string {
+	switch {
+	case change.Op == "" && deviation.HasBaseline:
+		return marshalValue(deviation.Baseline)
+	case change.Op == "" || change.Op == flatjson.ChangeRemove:
+		return "(missing)"
+	default:
+		return marshalValue(change.New)
+	}
+}
+
+// writeDeviations writes deviations to w grouped by path. Each path is followed
+// by the baseline value and the values of the deviating files.
+func writeDeviations(w io.Writer, deviations []flatjson.Deviation) error {
+	for _, deviation := range deviations {
+		sb := &strings.Builder{}
+		sb.WriteString(deviation.Path + "\n")
+		fmt.Fprintf(sb, "\t%s: %s\n", *baseline, deviationValue(deviation, flatjson.Change{}))
+		for i, change := range deviation.Changes {
+			if change.Op != "" {
+				fmt.Fprintf(sb, "\t%s: %s\n", pflag.Arg(i), deviationValue(deviation, change))
+			}
+		}
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDeviationsJSON writes deviations to w as a JSON array. Each element
+// has the path, the baseline value if any, and the changes from the baseline
+// to each deviating file, keyed by filename.
+func writeDeviationsJSON(w io.Writer, deviations []flatjson.Deviation) error {
+	type jsonDeviation struct {
+		Path     string                     `json:"path"`
+		Baseline json.RawMessage            `json:"baseline,omitempty"`
+		Targets  map[string]flatjson.Change `json:"targets"`
+	}
+	result := make([]jsonDeviation, 0, len(deviations))
+	for _, deviation := range deviations {
+		element := jsonDeviation{
+			Path:    deviation.Path,
+			Targets: make(map[string]flatjson.Change),
+		}
+		if deviation.HasBaseline {
+			element.Baseline = json.RawMessage(marshalValue(deviation.Baseline))
+		}
+		for i, change := range deviation.Changes {
+			if change.Op != "" {
+				element.Targets[pflag.Arg(i)] = change
+			}
+		}
+		result = append(result, element)
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(result)
+}
+
+// writeDeviationsMatrix writes deviations to w as tab-separated values with a
+// row for each path and a column for the baseline and each file. Values that
+// match the baseline are written as =.
+func writeDeviationsMatrix(w io.Writer, deviations []flatjson.Deviation) error {
+	sb := &strings.Builder{}
+	sb.WriteString("path\t" + *baseline)
+	for _, arg := range pflag.Args() {
+		sb.WriteString("\t" + arg)
+	}
+	sb.WriteString("\n")
+	for _, deviation := range deviations {
+		sb.WriteString(deviation.Path + "\t" + deviationValue(deviation, flatjson.Change{}))
+		for _, change := range deviation.Changes {
+			if change.Op == "" {
+				sb.WriteString("\t=")
+			} else {
+				sb.WriteString("\t" + deviationValue(deviation, change))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/twpayne/flatjson"
+)
+
+// runDirectoryDiff writes the differences between the input files in the
+// directories dirA and dirB to w, pairing them by relative path like diff -r,
+// and returns whether they differ. Each changed file gets its own diff with a/
+// and b/ headers. Added and removed files are compared with /dev/null.
+func runDirectoryDiff(w io.Writer, dirA, dirB string) (bool, error) {
+	if *format != "unified" {
+		return false, fmt.Errorf("--format=%s: not supported for directories", *format)
+	}
+	filesA, err := findInputFiles(dirA)
+	if err != nil {
+		return false, err
+	}
+	filesB, err := findInputFiles(dirB)
+	if err != nil {
+		return false, err
+	}
+	relPaths := make([]string, 0, len(filesA)+len(filesB))
+	for relPath := range filesA {
+		relPaths = append(relPaths, relPath)
+	}
+	for relPath := range filesB {
+		if !filesA[relPath] {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+	sort.Strings(relPaths)
+	differ, err := newDiffer()
+	if err != nil {
+		return false, err
+	}
+	colorOutput, err := useColor()
+	if err != nil {
+		return false, err
+	}
+	differences := false
+	var stats []flatjson.PathStat
+	for _, relPath := range relPaths {
+		a, err := readDiffFile(dirA, "a", relPath, filesA[relPath])
+		if err != nil {
+			return false, err
+		}
+		b, err := readDiffFile(dirB, "b", relPath, filesB[relPath])
+		if err != nil {
+			return false, err
+		}
+		moves, text, err := flattenDiffFiles(differ, a, b)
+		if err != nil {
+			return false, err
+		}
+		if len(moves) == 0 && text[0] == text[1] && a.exists == b.exists {
+			continue
+		}
+		differences = true
+		switch {
+		case *nameOnly:
+			if _, err := fmt.Fprintln(w, relPath); err != nil {
+				return false, err
+			}
+		case *stat:
+			pathStats, err := statDiff(moves, text, 0)
+			if err != nil {
+				return false, err
+			}
+			fileStat := flatjson.PathStat{Path: relPath}
+			for _, pathStat := range pathStats {
+				fileStat.Added += pathStat.Added
+				fileStat.Removed += pathStat.Removed
+				fileStat.Changed += pathStat.Changed
+				fileStat.Moved += pathStat.Moved
+			}
+			stats = append(stats, fileStat)
+		default:
+			header := "diff --flatjson a/" + relPath + " b/" + relPath
+			switch {
+			case !a.exists:
+				header += "\nnew file"
+			case !b.exists:
+				header += "\ndeleted file"
+			}
+			if colorOutput {
+				header = "\x1b[1m" + strings.ReplaceAll(header, "\n", "\x1b[m\n\x1b[1m") + "\x1b[m"
+			}
+			if _, err := fmt.Fprintln(w, header); err != nil {
+				return false, err
+			}
+			if err := writeUnifiedDiff(w, a, b, moves, text, colorOutput); err != nil {
+				return false, err
+			}
+		}
+	}
+	if *stat && !*nameOnly {
+		return differences, writeStat(w, stats, "file")
+	}
+	return differences, nil
+}
+
+// inputExtensions are the extensions of the files that are compared in
+// directories, in any of the formats detected by flatjson.DetectInputFormat.
+var inputExtensions = map[string]bool{
+	".flatjson": true,
+	".json":     true,
+	".jsonl":    true,
+	".ndjson":   true,
+}
+
+// findInputFiles returns the set of relative paths, separated by slashes, of
+// the files with one of inputExtensions in the directory tree rooted at dir.
+func findInputFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	if err := filepath.WalkDir(dir, func(name string, dirEntry fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case !dirEntry.Type().IsRegular() || !inputExtensions[strings.ToLower(filepath.Ext(name))]:
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// readDiffFile reads the file relPath in dir, named with prefix in diffs. If
+// exists is false then the file is named /dev/null.
+func readDiffFile(dir, prefix, relPath string, exists bool) (diffFile, error) {
+	if !exists {
+		return diffFile{name: "/dev/null"}, nil
+	}
+	value, err := readValueFromFile(filepath.Join(dir, filepath.FromSlash(relPath)))
+	if err != nil {
+		return diffFile{}, err
+	}
+	return diffFile{name: path.Join(prefix, relPath), value: value, exists: true}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// writeFiles writes files, a map of relative paths to contents, in dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for relPath, data := range files {
+		name := filepath.Join(dir, filepath.FromSlash(relPath))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0o700))
+		assert.NoError(t, os.WriteFile(name, []byte(data), 0o600))
+	}
+}
+
+func TestRunDirectoryDiff(t *testing.T) {
+	filesA := map[string]string{
+		"changed.json":     `{"a":1}`,
+		"removed.json":     `{"a":1}`,
+		"same.json":        `{"a":1}`,
+		"sub/changed.json": `[1,2]`,
+		"flat.flatjson":    "root.a = 1;\n",
+		"lines.ndjson":     "1\n2\n",
+		"lines.jsonl":      "1\n2\n",
+		"ignored.txt":      `x`,
+	}
+	filesB := map[string]string{
+		"added.json":       `{"a":1}`,
+		"changed.json":     `{"a":2}`,
+		"same.json":        `{"a":1}`,
+		"sub/changed.json": `[1]`,
+		"flat.flatjson":    "root.a = 2;\n",
+		"lines.ndjson":     "1\n2\n",
+		"lines.jsonl":      "1\n3\n",
+		"ignored.txt":      `y`,
+	}
+	for i, tc := range []struct {
+		setFlags func(*testing.T)
+		expected string
+	}{
+		{
+			setFlags: func(t *testing.T) {
+				t.Helper()
+				setFlag(t, nameOnly, true)
+			},
+			expected: "added.json\nchanged.json\nflat.flatjson\nlines.jsonl\nremoved.json\nsub/changed.json\n",
+		},
+		{
+			setFlags: func(t *testing.T) {
+				t.Helper()
+				setFlag(t, stat, true)
+			},
+			expected: strings.Join([]string{
+				" added.json       | 1 +1",
+				" changed.json     | 1 ~1",
+				" flat.flatjson    | 1 ~1",
+				" lines.jsonl      | 1 ~1",
+				" removed.json     | 1 -1",
+				" sub/changed.json | 1 -1",
+				" 6 files changed, 1 added(+), 2 removed(-), 3 changed(~), 0 moved(>)",
+				"",
+			}, "\n"),
+		},
+		{
+			expected: strings.Join([]string{
+				"diff --flatjson a/added.json b/added.json",
+				"new file",
+				"--- /dev/null",
+				"+++ b/added.json",
+				"@@ -0,0 +1,2 @@ root",
+				"+root = {};",
+				"+root.a = 1;",
+				"diff --flatjson a/changed.json b/changed.json",
+				"--- a/changed.json",
+				"+++ b/changed.json",
+				"@@ -1,3 +1,3 @@ root.a",
+				" root = {};",
+				"-root.a = 1;",
+				"+root.a = 2;",
+				" ",
+				"diff --flatjson a/flat.flatjson b/flat.flatjson",
+				"--- a/flat.flatjson",
+				"+++ b/flat.flatjson",
+				"@@ -1,3 +1,3 @@ root.a",
+				" root = {};",
+				"-root.a = 1;",
+				"+root.a = 2;",
+				" ",
+				"diff --flatjson a/lines.jsonl b/lines.jsonl",
+				"--- a/lines.jsonl",
+				"+++ b/lines.jsonl",
+				"@@ -1,4 +1,4 @@ root[1]",
+				" root = [];",
+				" root[0] = 1;",
+				"-root[1] = 2;",
+				"+root[1] = 3;",
+				" ",
+				"diff --flatjson a/removed.json b/removed.json",
+				"deleted file",
+				"--- a/removed.json",
+				"+++ /dev/null",
+				"@@ -1,2 +0,0 @@ root",
+				"-root = {};",
+				"-root.a = 1;",
+				"diff --flatjson a/sub/changed.json b/sub/changed.json",
+				"--- a/sub/changed.json",
+				"+++ b/sub/changed.json",
+				"@@ -1,4 +1,3 @@ root[1]",
+				" root = [];",
+				" root[0] = 1;",
+				"-root[1] = 2;",
+				" ",
+				"",
+			}, "\n"),
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			setFlag(t, color, "never")
+			if tc.setFlags != nil {
+				tc.setFlags(t)
+			}
+			dirA, dirB := t.TempDir(), t.TempDir()
+			writeFiles(t, dirA, filesA)
+			writeFiles(t, dirB, filesB)
+			sb := &strings.Builder{}
+			differences, err := runDirectoryDiff(sb, dirA, dirB)
+			assert.NoError(t, err)
+			assert.True(t, differences)
+			assert.Equal(t, tc.expected, sb.String())
+		})
+	}
+}
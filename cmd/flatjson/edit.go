@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/twpayne/flatjson"
+)
+
+// runGet writes the JSON value at each path given by the arguments after the
+// filename.
+func runGet(args []string) error {
+	if len(args) < 2 {
+		return errors.New("get requires a filename and at least one path")
+	}
+	root, err := readValueFromFile(args[0])
+	if err != nil {
+		return err
+	}
+	e := json.NewEncoder(os.Stdout)
+	e.SetEscapeHTML(false)
+	for _, path := range args[1:] {
+		value, err := flatjson.GetPath(root, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		if err := e.Encode(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A pathEdit sets or deletes the value at a path.
+type pathEdit struct {
+	path   string
+	value  interface{}
+	delete bool
+}
+
+// runSet sets the value at each PATH=VALUE given by the arguments after the
+// filename and writes the result.
+func runSet(args []string) error {
+	if len(args) < 2 {
+		return errors.New("set requires a filename and at least one PATH=VALUE")
+	}
+	edits := make([]pathEdit, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		path, value, err := flatjson.ParseAssignment(arg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		edits = append(edits, pathEdit{path: path, value: value})
+	}
+	return editFile(args[0], edits)
+}
+
+// runDel deletes the value at each path given by the arguments after the
+// filename and writes the result.
+func runDel(args []string) error {
+	if len(args) < 2 {
+		return errors.New("del requires a filename and at least one path")
+	}
+	edits := make([]pathEdit, 0, len(args)-1)
+	for _, path := range args[1:] {
+		edits = append(edits, pathEdit{path: path, delete: true})
+	}
+	return editFile(args[0], edits)
+}
+
+// editFile reads the file named filename, applies edits to it, and writes the
+// result in the same format to stdout or, with --in-place, back to the file.
+// JSON is edited in place, so the order of properties and the layout of the
+// rest of the file are preserved. NDJSON is edited one line at a time.
+func editFile(filename string, edits []pathEdit) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	format := parseInputFormat()
+	if format == flatjson.InputFormatAuto {
+		format = flatjson.DetectInputFormat(data)
+	}
+	switch format {
+	case flatjson.InputFormatJSON:
+		for _, edit := range edits {
+			if data, err = edit.applyJSON(data); err != nil {
+				return fmt.Errorf("%s: %w", filename, err)
+			}
+		}
+	case flatjson.InputFormatNDJSON:
+		if data, err = editNDJSON(data, edits); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	default:
+		root, err := flatjson.UnmarshalInput(data, format)
+		if err != nil {
+			return err
+		}
+		for _, edit := range edits {
+			if root, err = edit.apply(root); err != nil {
+				return fmt.Errorf("%s: %w", filename, err)
+			}
+		}
+		buffer := &bytes.Buffer{}
+		if err := flatjson.NewFlattener(buffer, flattenerOptions()...).WriteValue(root); err != nil {
+			return err
+		}
+		data = buffer.Bytes()
+	}
+	if !*inPlace {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return writeFileAtomically(filename, data)
+}
+
+// apply applies e to root and returns the new root.
+func (e pathEdit) apply(root interface{}) (interface{}, error) {
+	if e.delete {
+		return flatjson.DeletePath(root, e.path)
+	}
+	return flatjson.SetPath(root, e.path, e.value)
+}
+
+// applyJSON applies e to data, a JSON document, in place.
+func (e pathEdit) applyJSON(data []byte) ([]byte, error) {
+	if e.delete {
+		return flatjson.DeleteJSONPath(data, e.path)
+	}
+	return flatjson.SetJSONPath(data, e.path, e.value)
+}
+
+// editNDJSON applies edits to data, in which each line is a JSON value and
+// which is edited as an array with one element for each non-blank line. Lines
+// are edited in place and lines that are not edited, including blank lines,
+// are unchanged.
+func editNDJSON(data []byte, edits []pathEdit) ([]byte, error) {
+	root, err := flatjson.UnmarshalInput(data, flatjson.InputFormatNDJSON)
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	// valueLines maps the index of each element of root to its line.
+	var valueLines []int
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) > 0 {
+			valueLines = append(valueLines, i)
+		}
+	}
+	if len(valueLines) != len(root.([]interface{})) {
+		return nil, errors.New("NDJSON must contain one value per line")
+	}
+	for _, edit := range edits {
+		// Check the edit and get its errors from the value of data.
+		if root, err = edit.apply(root); err != nil {
+			return nil, err
+		}
+		identifier, properties, err := flatjson.ParsePath(edit.path)
+		if err != nil {
+			return nil, err
+		}
+		if len(properties) == 0 {
+			return nil, fmt.Errorf("%s: cannot set NDJSON root", edit.path)
+		}
+		index, ok := properties[0].(int)
+		if !ok {
+			return nil, fmt.Errorf("%s: NDJSON root must be an array", edit.path)
+		}
+		switch {
+		case index == len(valueLines):
+			// The edit appended an element, so append a line after the last
+			// value, keeping the ending of the file.
+			value, err := encodeNDJSONLine(root.([]interface{})[index])
+			if err != nil {
+				return nil, err
+			}
+			i := 0
+			if n := len(valueLines); n > 0 {
+				i = valueLines[n-1] + 1
+				if prev := lines[i-1]; !bytes.HasSuffix(prev, []byte("\n")) {
+					lines[i-1] = append(prev, '\n')
+					value = bytes.TrimSuffix(value, []byte("\n"))
+				}
+			}
+			lines = append(lines[:i], append([][]byte{value}, lines[i:]...)...)
+			valueLines = append(valueLines, i)
+		case len(properties) > 1:
+			edit.path = flatjson.FormatPath(identifier, properties[1:])
+			i := valueLines[index]
+			if lines[i], err = edit.applyJSON(lines[i]); err != nil {
+				return nil, err
+			}
+		case edit.delete:
+			i := valueLines[index]
+			lines = append(lines[:i], lines[i+1:]...)
+			valueLines = append(valueLines[:index], valueLines[index+1:]...)
+			for j := index; j < len(valueLines); j++ {
+				valueLines[j]--
+			}
+		default:
+			value, err := encodeNDJSONLine(edit.value)
+			if err != nil {
+				return nil, err
+			}
+			i := valueLines[index]
+			lines[i] = append(bytes.TrimSuffix(value, []byte("\n")), lineEnding(lines[i])...)
+		}
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// encodeNDJSONLine returns value encoded as a line of NDJSON, including the
+// trailing newline.
+func encodeNDJSONLine(value interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	e := json.NewEncoder(buffer)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// lineEnding returns the line ending of line, which is empty for the last
+// line of a file that does not end with a newline.
+func lineEnding(line []byte) []byte {
+	switch {
+	case bytes.HasSuffix(line, []byte("\r\n")):
+		return []byte("\r\n")
+	case bytes.HasSuffix(line, []byte("\n")):
+		return []byte("\n")
+	default:
+		return nil
+	}
+}
+
+// writeFileAtomically replaces the contents of the file named filename with
+// data by writing a temporary file in the same directory and renaming it, so
+// that readers never see a partially written file. The file's permissions are
+// preserved.
+func writeFileAtomically(filename string, data []byte) (err error) {
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Chmod(fileInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
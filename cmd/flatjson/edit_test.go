@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// setFlag sets the flag value pointed to by p to value for the duration of
+// the test.
+func setFlag[T any](t *testing.T, p *T, value T) {
+	t.Helper()
+	oldValue := *p
+	*p = value
+	t.Cleanup(func() {
+		*p = oldValue
+	})
+}
+
+func TestEditNDJSON(t *testing.T) {
+	for i, tc := range []struct {
+		data          string
+		edits         []pathEdit
+		expected      string
+		expectedError string
+	}{
+		{
+			data:     "{\"a\":1}\n{\"a\":2}\n",
+			edits:    []pathEdit{{path: "root[1].a", value: 3.0}},
+			expected: "{\"a\":1}\n{\"a\":3}\n",
+		},
+		{
+			data:     "{\"a\":1}\n{\"a\":2}\n",
+			edits:    []pathEdit{{path: "root[2].a", value: 3.0}},
+			expected: "{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n",
+		},
+		{
+			data:     "{\"a\":1}\n{\"a\":2}",
+			edits:    []pathEdit{{path: "root[2]", value: "x"}},
+			expected: "{\"a\":1}\n{\"a\":2}\n\"x\"",
+		},
+		{
+			data:     "",
+			edits:    []pathEdit{{path: "root[0]", value: true}},
+			expected: "true\n",
+		},
+		{
+			data:     "1\n\n2\n\n",
+			edits:    []pathEdit{{path: "root[1]", value: 3.0}},
+			expected: "1\n\n3\n\n",
+		},
+		{
+			data:     "1\n\n2\n\n",
+			edits:    []pathEdit{{path: "root[2]", value: 3.0}},
+			expected: "1\n\n2\n3\n\n",
+		},
+		{
+			data:     "1\n\n2\n3\n",
+			edits:    []pathEdit{{path: "root[0]", delete: true}, {path: "root[1]", value: 4.0}},
+			expected: "\n2\n4\n",
+		},
+		{
+			data:     "1\r\n2",
+			edits:    []pathEdit{{path: "root[0]", value: 3.0}, {path: "root[1]", value: 4.0}},
+			expected: "3\r\n4",
+		},
+		{
+			data:     "{\"b\":1,\"a\":2}\n",
+			edits:    []pathEdit{{path: "root[0].a", delete: true}},
+			expected: "{\"b\":1}\n",
+		},
+		{
+			data:          "1\n",
+			edits:         []pathEdit{{path: "root[3]", value: 1.0}},
+			expectedError: "root[3]: index out of range",
+		},
+		{
+			data:          "1\n",
+			edits:         []pathEdit{{path: "root", value: 1.0}},
+			expectedError: "root: cannot set NDJSON root",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := editNDJSON([]byte(tc.data), tc.edits)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestEditFile(t *testing.T) {
+	for i, tc := range []struct {
+		filename    string
+		data        string
+		edits       []pathEdit
+		setFlags    func(*testing.T)
+		expected    string
+		expectedErr bool
+	}{
+		{
+			filename: "a.json",
+			data:     "{\n  \"b\": 1,\n  \"a\": 2\n}\n",
+			edits:    []pathEdit{{path: "root.a", value: 3.0}},
+			expected: "{\n  \"b\": 1,\n  \"a\": 3\n}\n",
+		},
+		{
+			filename: "a.ndjson",
+			data:     "{\"a\":1}\n{\"a\":2}\n",
+			edits:    []pathEdit{{path: "root[2].a", value: 3.0}},
+			expected: "{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n",
+		},
+		{
+			filename: "a.js",
+			data:     "root = {};\nroot.a = 1;\n",
+			edits:    []pathEdit{{path: "root.b", value: "x"}},
+			expected: "root = {};\nroot.a = 1;\nroot.b = \"x\";\n",
+		},
+		{
+			filename: "a.js",
+			data:     "root.données = {};\nroot.données.a = 1;\n",
+			edits:    []pathEdit{{path: "root.données.b", value: 2.0}},
+			setFlags: func(t *testing.T) {
+				t.Helper()
+				setFlag(t, utf8, true)
+				setFlag(t, omitContainers, true)
+			},
+			expected: "root.données.a = 1;\nroot.données.b = 2;\n",
+		},
+		{
+			filename:    "a.json",
+			data:        "{}",
+			edits:       []pathEdit{{path: "root.a.b", delete: true}},
+			expectedErr: true,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tc.setFlags != nil {
+				tc.setFlags(t)
+			}
+			setFlag(t, inPlace, true)
+			filename := filepath.Join(t.TempDir(), tc.filename)
+			assert.NoError(t, os.WriteFile(filename, []byte(tc.data), 0o600))
+			err := editFile(filename, tc.edits)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			actual, err := os.ReadFile(filename)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
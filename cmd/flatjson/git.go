@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/flatjson"
+)
+
+// gitOutput runs git with args and returns its standard output. If git fails
+// then the returned error includes its standard error.
+func gitOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), message)
+		}
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+// gitObjectExists returns whether revisionPath, a path at a git revision,
+// exists.
+func gitObjectExists(revisionPath string) bool {
+	return exec.Command("git", "cat-file", "-e", revisionPath).Run() == nil
+}
+
+// gitTopLevelPath returns absPath, an absolute path, relative to the top of
+// the git repository, as used in git revision paths.
+func gitTopLevelPath(absPath string) (string, error) {
+	output, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	topLevel := strings.TrimSuffix(string(output), "\n")
+	path := absPath
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		path = filepath.Join(dir, filepath.Base(path))
+	}
+	relPath, err := filepath.Rel(topLevel, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: outside git repository %s", absPath, topLevel)
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+// isGitRevisionPath returns whether arg is a path at a git revision, like
+// HEAD~1:config/app.json, rather than a file. The revision must exist.
+func isGitRevisionPath(arg string) bool {
+	if _, err := os.Lstat(arg); err == nil {
+		return false
+	}
+	revision, path, ok := strings.Cut(arg, ":")
+	if !ok || revision == "" || path == "" {
+		return false
+	}
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", revision+"^{tree}").Run() == nil
+}
+
+// readValueFromGit reads a value from revisionPath, a path at a git revision,
+// in the format given by --input-format. Absolute paths are made relative to
+// the top of the repository.
+func readValueFromGit(revisionPath string) (interface{}, error) {
+	if revision, path, _ := strings.Cut(revisionPath, ":"); filepath.IsAbs(path) {
+		topLevelPath, err := gitTopLevelPath(path)
+		if err != nil {
+			return nil, err
+		}
+		revisionPath = revision + ":" + topLevelPath
+	}
+	data, err := gitOutput("show", revisionPath)
+	if err != nil {
+		return nil, err
+	}
+	return flatjson.UnmarshalInput(data, parseInputFormat())
+}
+
+// runLog writes the diff of the flat writes of the file named by the argument
+// for each git commit that touched it, newest first, like git log -p.
+func runLog(args []string) error {
+	if len(args) != 1 {
+		return errors.New("log requires exactly one filename")
+	}
+	if *format != "unified" {
+		return fmt.Errorf("--format=%s: not supported by log", *format)
+	}
+	// Paths starting with ./ are relative to the current directory in git
+	// revision paths.
+	path := "./" + filepath.ToSlash(args[0])
+	if filepath.IsAbs(args[0]) {
+		var err error
+		if path, err = gitTopLevelPath(args[0]); err != nil {
+			return err
+		}
+	}
+	output, err := gitOutput("log", "--format=%H%x00%h%x00%an <%ae>%x00%ad%x00%s", "--", args[0])
+	if err != nil {
+		return err
+	}
+	differ, err := newDiffer()
+	if err != nil {
+		return err
+	}
+	colorOutput, err := useColor()
+	if err != nil {
+		return err
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(string(output), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\x00", 5)
+		if len(fields) != 5 {
+			return fmt.Errorf("%s: invalid git log output", line)
+		}
+		commit, abbreviatedCommit, author, date, subject := fields[0], fields[1], fields[2], fields[3], fields[4]
+		a, err := readDiffFileFromGit(abbreviatedCommit + "^:" + path)
+		if err != nil {
+			return err
+		}
+		b, err := readDiffFileFromGit(abbreviatedCommit + ":" + path)
+		if err != nil {
+			return err
+		}
+		moves, text, err := flattenDiffFiles(differ, a, b)
+		if err != nil {
+			return err
+		}
+		header := "commit " + commit
+		if colorOutput {
+			header = "\x1b[33m" + header + "\x1b[m"
+		}
+		if i > 0 {
+			header = "\n" + header
+		}
+		if _, err := fmt.Printf("%s\nAuthor: %s\nDate:   %s\n\n    %s\n\n", header, author, date, subject); err != nil {
+			return err
+		}
+		if err := writeUnifiedDiff(os.Stdout, a, b, moves, text, colorOutput); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDiffFileFromGit reads revisionPath, a path at a git revision. If the
+// path does not exist at the revision, for example because the revision
+// created or deleted it, then the file is named /dev/null.
+func readDiffFileFromGit(revisionPath string) (diffFile, error) {
+	if !gitObjectExists(revisionPath) {
+		return diffFile{name: "/dev/null"}, nil
+	}
+	value, err := readValueFromGit(revisionPath)
+	if err != nil {
+		return diffFile{}, err
+	}
+	return diffFile{name: revisionPath, value: value, exists: true}, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// newGitRepo creates a git repository containing files, a map of relative
+// paths to contents, in a single commit, and changes into its sub directory.
+func newGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	writeFiles(t, repo, files)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message=Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		output, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(output))
+	}
+	t.Chdir(filepath.Join(repo, "sub"))
+	return repo
+}
+
+func TestIsGitRevisionPath(t *testing.T) {
+	newGitRepo(t, map[string]string{
+		"a.json":     `{"a":1}`,
+		"sub/b.json": `{"b":2}`,
+		"sub/c:d":    `{}`,
+	})
+	for i, tc := range []struct {
+		arg      string
+		expected bool
+	}{
+		{arg: "HEAD:a.json", expected: true},
+		{arg: "HEAD:./b.json", expected: true},
+		{arg: "HEAD:nonexistent.json", expected: true},
+		{arg: "nosuchrev:a.json"},
+		{arg: ":a.json"},
+		{arg: "HEAD:"},
+		{arg: "b.json"},
+		{arg: "c:d"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, isGitRevisionPath(tc.arg))
+		})
+	}
+}
+
+func TestReadValueFromGit(t *testing.T) {
+	repo := newGitRepo(t, map[string]string{
+		"a.json":     `{"a":1}`,
+		"sub/b.json": `{"b":2}`,
+	})
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	for i, tc := range []struct {
+		revisionPath  string
+		expected      interface{}
+		expectedError bool
+	}{
+		{
+			revisionPath: "HEAD:a.json",
+			expected:     map[string]interface{}{"a": json.Number("1")},
+		},
+		{
+			revisionPath: "HEAD:sub/b.json",
+			expected:     map[string]interface{}{"b": json.Number("2")},
+		},
+		{
+			revisionPath: "HEAD:./b.json",
+			expected:     map[string]interface{}{"b": json.Number("2")},
+		},
+		{
+			revisionPath: "HEAD:../a.json",
+			expected:     map[string]interface{}{"a": json.Number("1")},
+		},
+		{
+			revisionPath: "HEAD:" + filepath.Join(repo, "a.json"),
+			expected:     map[string]interface{}{"a": json.Number("1")},
+		},
+		{
+			revisionPath: "HEAD:" + filepath.Join(repo, "sub", "b.json"),
+			expected:     map[string]interface{}{"b": json.Number("2")},
+		},
+		{
+			revisionPath:  "HEAD:" + filepath.Join(outside, "a.json"),
+			expectedError: true,
+		},
+		{
+			revisionPath:  "HEAD:nonexistent.json",
+			expectedError: true,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := readValueFromGit(tc.revisionPath)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestReadDiffFileFromGit(t *testing.T) {
+	newGitRepo(t, map[string]string{
+		"sub/b.json": `{"b":2}`,
+	})
+	actual, err := readDiffFileFromGit("HEAD:./b.json")
+	assert.NoError(t, err)
+	assert.Equal(t, diffFile{name: "HEAD:./b.json", value: map[string]interface{}{"b": json.Number("2")}, exists: true}, actual)
+
+	actual, err = readDiffFileFromGit("HEAD:./nonexistent.json")
+	assert.NoError(t, err)
+	assert.Equal(t, diffFile{name: "/dev/null"}, actual)
+}
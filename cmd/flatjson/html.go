@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/twpayne/flatjson"
+)
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .FromFile }} → {{ .ToFile }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+code { font-family: monospace; }
+details, .leaf { margin-left: 1.5em; }
+summary { cursor: pointer; }
+.add { color: #1a7f37; }
+.remove { color: #cf222e; }
+.replace { color: #9a6700; }
+.move { color: #0969da; }
+.old { background: #ffebe9; }
+.new { background: #dafbe1; }
+.summary td { padding: 0.2em 1em 0.2em 0; }
+</style>
+</head>
+<body>
+<h1><code>{{ .FromFile }}</code> → <code>{{ .ToFile }}</code></h1>
+<table class="summary">
+<tr><td class="add">Added</td><td>{{ .Added }}</td></tr>
+<tr><td class="remove">Removed</td><td>{{ .Removed }}</td></tr>
+<tr><td class="replace">Changed</td><td>{{ .Changed }}</td></tr>
+<tr><td class="move">Moved</td><td>{{ len .Moves }}</td></tr>
+</table>
+{{- if .Moves }}
+<h2>Moves</h2>
+<ul>
+{{- range .Moves }}
+<li class="move"><code>{{ .From }}</code> → <code>{{ .Path }}</code></li>
+{{- end }}
+</ul>
+{{- end }}
+<h2>Changes</h2>
+{{- if .Root }}
+{{ template "node" .Root }}
+{{- else }}
+<p>No differences.</p>
+{{- end }}
+</body>
+</html>
+{{ define "node" -}}
+{{ if .Children -}}
+<details open>
+<summary>{{ template "change" . }}</summary>
+{{- range .Children }}
+{{ template "node" . }}
+{{- end }}
+</details>
+{{- else -}}
+<div class="leaf">{{ template "change" . }}</div>
+{{- end }}
+{{- end }}
+{{ define "change" -}}
+<code>{{ .Name }}</code>
+{{- with .Op }} <span class="{{ . }}">{{ . }}</span>{{ end }}
+{{- if .HasOld }} <code class="old">{{ .Old }}</code>{{ end }}
+{{- if .HasNew }} <code class="new">{{ .New }}</code>{{ end }}
+{{- end }}
+`))
+
+// An htmlNode is a node in the tree of changed paths in an HTML report.
+type htmlNode struct {
+	Name     string
+	Op       flatjson.ChangeOp
+	Old      string
+	HasOld   bool
+	New      string
+	HasNew   bool
+	Children []*htmlNode
+
+	path     string
+	children map[string]*htmlNode
+}
+
+// An htmlReport is an HTML report of the differences between two files.
+type htmlReport struct {
+	FromFile string
+	ToFile   string
+	Added    int
+	Removed  int
+	Changed  int
+	Moves    []flatjson.Change
+	Root     *htmlNode
+}
+
+// child returns the child of n with the given path, creating it if needed.
+func (n *htmlNode) child(path string) *htmlNode {
+	if child, ok := n.children[path]; ok {
+		return child
+	}
+	child := &htmlNode{
+		Name:     strings.TrimPrefix(path, n.path),
+		path:     path,
+		children: make(map[string]*htmlNode),
+	}
+	n.children[path] = child
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// writeHTMLReport writes a self-contained HTML report of changes and moves
+// between the files fromFile and toFile to w.
+func writeHTMLReport(w io.Writer, fromFile, toFile string, changes, moves []flatjson.Change) error {
+	report, err := newHTMLReport(fromFile, toFile, changes, moves)
+	if err != nil {
+		return err
+	}
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// newHTMLReport returns the report of changes and moves between the files
+// fromFile and toFile, with the changes arranged in a tree of paths. Like
+// --stat, the summary counts only changes to leaves.
+func newHTMLReport(fromFile, toFile string, changes, moves []flatjson.Change) (*htmlReport, error) {
+	report := &htmlReport{
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Moves:    moves,
+	}
+	stats, err := flatjson.StatChanges(changes, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range stats {
+		report.Added += stat.Added
+		report.Removed += stat.Removed
+		report.Changed += stat.Changed
+	}
+	for _, change := range changes {
+		identifier, properties, err := flatjson.ParsePath(change.Path)
+		if err != nil {
+			return nil, err
+		}
+		if report.Root == nil {
+			report.Root = &htmlNode{
+				Name:     identifier,
+				path:     identifier,
+				children: make(map[string]*htmlNode),
+			}
+		}
+		node := report.Root
+		for i := range properties {
+			path, err := flatjson.AncestorPath(change.Path, i+1)
+			if err != nil {
+				return nil, err
+			}
+			node = node.child(path)
+		}
+		node.Op = change.Op
+		if change.Op != flatjson.ChangeAdd {
+			node.Old, node.HasOld = marshalValue(change.Old), true
+		}
+		if change.Op != flatjson.ChangeRemove {
+			node.New, node.HasNew = marshalValue(change.New), true
+		}
+	}
+	return report, nil
+}
+
+// marshalValue returns the JSON encoding of value.
+func marshalValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/flatjson"
+)
+
+// treeLines returns the nodes of the tree rooted at n, one per line, indented
+// by depth.
+func treeLines(n *htmlNode, depth int) []string {
+	if n == nil {
+		return nil
+	}
+	line := strings.Repeat("  ", depth) + n.Name
+	if n.Op != "" {
+		line += " " + string(n.Op)
+	}
+	if n.HasOld {
+		line += " " + n.Old
+	}
+	if n.HasNew {
+		line += " " + n.New
+	}
+	lines := []string{line}
+	for _, child := range n.Children {
+		lines = append(lines, treeLines(child, depth+1)...)
+	}
+	return lines
+}
+
+func TestNewHTMLReport(t *testing.T) {
+	for i, tc := range []struct {
+		changes         []flatjson.Change
+		moves           []flatjson.Change
+		expectedAdded   int
+		expectedRemoved int
+		expectedChanged int
+		expectedTree    []string
+	}{
+		{},
+		{
+			changes: []flatjson.Change{
+				{Op: flatjson.ChangeAdd, Path: "root.a", New: map[string]interface{}{}},
+				{Op: flatjson.ChangeAdd, Path: "root.a.b", New: 1.0},
+				{Op: flatjson.ChangeAdd, Path: "root.a.c", New: "x"},
+				{Op: flatjson.ChangeRemove, Path: "root.d", Old: []interface{}{}},
+				{Op: flatjson.ChangeRemove, Path: "root.d[0]", Old: true},
+				{Op: flatjson.ChangeReplace, Path: "root.e", Old: 1.0, New: 2.0},
+				{Op: flatjson.ChangeAdd, Path: "root.f", New: []interface{}{}},
+			},
+			moves: []flatjson.Change{
+				{Op: flatjson.ChangeMove, Path: "root.g[0]", From: "root.g[1]"},
+			},
+			expectedAdded:   3,
+			expectedRemoved: 1,
+			expectedChanged: 1,
+			expectedTree: []string{
+				"root",
+				"  .a add {}",
+				"    .b add 1",
+				`    .c add "x"`,
+				"  .d remove []",
+				"    [0] remove true",
+				"  .e replace 1 2",
+				"  .f add []",
+			},
+		},
+		{
+			changes: []flatjson.Change{
+				{Op: flatjson.ChangeReplace, Path: `root["a-b"].c[1]`, Old: nil, New: "<"},
+				{Op: flatjson.ChangeReplace, Path: "root.données.x", Old: 1.0, New: 2.0},
+			},
+			expectedChanged: 2,
+			expectedTree: []string{
+				"root",
+				`  ["a-b"]`,
+				"    .c",
+				`      [1] replace null "\u003c"`,
+				"  .données",
+				"    .x replace 1 2",
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			report, err := newHTMLReport("a.json", "b.json", tc.changes, tc.moves)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAdded, report.Added)
+			assert.Equal(t, tc.expectedRemoved, report.Removed)
+			assert.Equal(t, tc.expectedChanged, report.Changed)
+			assert.Equal(t, tc.moves, report.Moves)
+			assert.Equal(t, tc.expectedTree, treeLines(report.Root, 0))
+		})
+	}
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	sb := &strings.Builder{}
+	assert.NoError(t, writeHTMLReport(sb, "a.json", "b.json", []flatjson.Change{
+		{Op: flatjson.ChangeReplace, Path: "root.a", Old: "<x>", New: "y"},
+	}, nil))
+	actual := sb.String()
+	assert.Contains(t, actual, `<tr><td class="replace">Changed</td><td>1</td></tr>`)
+	assert.Contains(t, actual, `<code>.a</code> <span class="replace">replace</span>`)
+	assert.NotContains(t, actual, "<x>")
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,69 +10,375 @@ import (
 	"os"
 	"strings"
 
-	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/pflag"
 
 	"github.com/twpayne/flatjson"
 )
 
 var (
-	context = pflag.Int("context", 3, "context")
-	diff    = pflag.Bool("diff", false, "diff")
-	prefix  = pflag.String("prefix", "root", "prefix.")
-	suffix  = pflag.String("suffix", ";\n", "suffix.")
-	reverse = pflag.Bool("reverse", false, "reverse")
+	absTolerance      = pflag.Float64("abs-tolerance", 0, "absolute tolerance of numbers")
+	annotations       = pflag.String("annotations", "none", "annotate sizes of containers (none, length, or comment)")
+	baseline          = pflag.String("baseline", "", "compare files with baseline file")
+	color             = pflag.String("color", "auto", "color (auto, always, or never)")
+	context           = pflag.Int("context", 3, "context")
+	depth             = pflag.Int("depth", 0, "aggregate changes up to path depth")
+	detectMoves       = pflag.Bool("detect-moves", false, "detect moved array elements")
+	diff              = pflag.Bool("diff", false, "diff")
+	embeddedJSON      = pflag.Bool("embedded-json", false, "flatten strings that contain JSON")
+	format            = pflag.String("format", "unified", "diff format (unified, html, json, or matrix)")
+	fuzz              = pflag.Int("fuzz", 2, "maximum context lines to ignore when applying diffs")
+	identityFields    = pflag.StringSlice("identity-field", nil, "identity field of array elements")
+	ignore            = pflag.StringArray("ignore", nil, "pattern of paths to ignore")
+	ignoreFiles       = pflag.StringArray("ignore-file", nil, "file containing patterns of paths to ignore")
+	inPlace           = pflag.Bool("in-place", false, "edit files in place")
+	inputFormat       = pflag.String("input-format", "auto", "input format (auto, json, flat, or ndjson)")
+	maxDepth          = pflag.Int("max-depth", 0, "write values below depth as JSON")
+	nameOnly          = pflag.Bool("name-only", false, "write only changed paths")
+	nullEqualsMissing = pflag.Bool("null-equals-missing", false, "treat null and missing properties as equal")
+	numeric           = pflag.Bool("numeric", false, "compare numbers by value")
+	numericStrings    = pflag.Bool("numeric-strings", false, "compare strings containing numbers by value")
+	omitContainers    = pflag.Bool("omit-containers", false, "omit declarations of non-empty objects and arrays")
+	prefix            = pflag.String("prefix", "root", "prefix.")
+	redact            = pflag.String("redact", "", "placeholder for ignored values")
+	relTolerance      = pflag.Float64("rel-tolerance", 0, "relative tolerance of numbers")
+	suffix            = pflag.String("suffix", ";\n", "suffix.")
+	reverse           = pflag.Bool("reverse", false, "reverse")
+	sideBySide        = pflag.Bool("side-by-side", false, "write diff in two columns")
+	stat              = pflag.Bool("stat", false, "write counts of changes by subtree")
+	strict            = pflag.Bool("strict", false, "check annotated sizes of containers when reversing")
+	templateLiterals  = pflag.Bool("template-literals", false, "write multiline strings as template literals")
+	unorderedArrays   = pflag.StringArray("unordered-arrays", nil, "pattern of paths of arrays to sort")
+	utf8              = pflag.Bool("utf8", false, "escape only what JSON requires and write non-ASCII identifiers with dot notation")
+	width             = pflag.Int("width", 130, "width of side-by-side diff")
+	wordDiff          = pflag.Bool("word-diff", false, "show changed words")
 )
 
-// mergeValuesFromFile reads flat JSON from the file named filename and merges
-// it into root.
-func mergeValuesFromFile(d *flatjson.Deepener, root interface{}, filename string) (interface{}, error) {
-	r, err := os.Open(filename)
+// mergeValues merges the input in data into root. Flat JSON is merged
+// assignment by assignment, other formats are flattened first.
+func mergeValues(d *flatjson.Deepener, root interface{}, data []byte) (interface{}, error) {
+	format := parseInputFormat()
+	if format == flatjson.InputFormatAuto {
+		format = flatjson.DetectInputFormat(data)
+	}
+	if format != flatjson.InputFormatFlat {
+		value, err := flatjson.UnmarshalInput(data, format)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = flatjson.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return d.MergeValues(root, bytes.NewReader(data))
+}
+
+// newDiffer returns a new Differ configured from the command line.
+func newDiffer() (*flatjson.Differ, error) {
+	options := []flatjson.DifferOption{
+		flatjson.DifferPrefix(*prefix),
+	}
+	ignorePatterns := *ignore
+	for _, ignoreFile := range *ignoreFiles {
+		patterns, err := readPatternsFromFile(ignoreFile)
+		if err != nil {
+			return nil, err
+		}
+		ignorePatterns = append(ignorePatterns, patterns...)
+	}
+	if len(ignorePatterns) > 0 {
+		options = append(options, flatjson.DifferIgnore(ignorePatterns...))
+	}
+	if *redact != "" {
+		options = append(options, flatjson.DifferRedact(*redact))
+	}
+	if *detectMoves || len(*identityFields) > 0 {
+		options = append(options, flatjson.DifferDetectMoves(*identityFields...))
+	}
+	if len(*unorderedArrays) > 0 {
+		options = append(options, flatjson.DifferUnorderedArrays(*unorderedArrays...))
+	}
+	if *numeric {
+		options = append(options, flatjson.DifferNumericEquality())
+	}
+	if *numericStrings {
+		options = append(options, flatjson.DifferNumericStrings())
+	}
+	if *absTolerance != 0 {
+		options = append(options, flatjson.DifferAbsTolerance(*absTolerance))
+	}
+	if *relTolerance != 0 {
+		options = append(options, flatjson.DifferRelTolerance(*relTolerance))
+	}
+	if *nullEqualsMissing {
+		options = append(options, flatjson.DifferNullEqualsMissing())
+	}
+	if *utf8 {
+		options = append(options, flatjson.DifferUTF8())
+	}
+	return flatjson.NewDiffer(options...), nil
+}
+
+// readPatternsFromFile reads patterns from the file named filename. Each line
+// contains a pattern. Blank lines and lines beginning with # are ignored.
+func readPatternsFromFile(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
-	return d.MergeValues(root, r)
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// parseInputFormat returns the input format given by --input-format.
+func parseInputFormat() flatjson.InputFormat {
+	if *inputFormat == "auto" {
+		return flatjson.InputFormatAuto
+	}
+	return flatjson.InputFormat(*inputFormat)
+}
+
+// readValueFromFile reads a value from the file named filename in the format
+// given by --input-format.
+func readValueFromFile(filename string) (interface{}, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return flatjson.UnmarshalInput(data, parseInputFormat())
+}
+
+// A diffFile is one side of a diff. If exists is false then the file does not
+// exist and is compared as empty.
+type diffFile struct {
+	name   string
+	value  interface{}
+	exists bool
+}
+
+// flattenDiffFiles returns the moved array elements between a and b and the
+// flat writes of a and b after normalization.
+func flattenDiffFiles(differ *flatjson.Differ, a, b diffFile) ([]flatjson.Change, [2]string, error) {
+	var moves []flatjson.Change
+	var text [2]string
+	if a.exists && b.exists {
+		for _, change := range differ.Diff(a.value, b.value) {
+			if change.Op == flatjson.ChangeMove {
+				moves = append(moves, change)
+			}
+		}
+		a.value, b.value = differ.Normalize(a.value, b.value)
+	}
+	for i, file := range []diffFile{a, b} {
+		if !file.exists {
+			continue
+		}
+		sb := &strings.Builder{}
+		f := flatjson.NewFlattener(sb, flattenerOptions()...)
+		if err := f.WriteValue(file.value); err != nil {
+			return nil, text, err
+		}
+		text[i] = sb.String()
+	}
+	return moves, text, nil
 }
 
 // runDiff writes the diff of the flat writes of the two files specified on the
-// command line.
-func runDiff() error {
+// command line in the format given by --format and returns whether the files
+// differ. In unified format, moved array elements are written before the diff.
+// If both arguments are directories then runDiff compares the JSON files in
+// them. Arguments like REV:path are read from git.
+func runDiff() (bool, error) {
 	if len(pflag.Args()) != 2 {
-		return errors.New("-diff requires exactly two filenames")
+		return false, errors.New("-diff requires exactly two filenames")
 	}
-	text := make([]string, 0, pflag.NArg())
+	var dirs int
 	for _, arg := range pflag.Args() {
-		sb := &strings.Builder{}
-		f := flatjson.NewFlattener(sb, flatjson.WithPrefix(*prefix), flatjson.WithSuffix(*suffix))
-		if err := writeValuesFromFile(f, arg); err != nil {
+		if isGitRevisionPath(arg) {
+			continue
+		}
+		fileInfo, err := os.Stat(arg)
+		if err != nil {
+			return false, err
+		}
+		if fileInfo.IsDir() {
+			dirs++
+		}
+	}
+	switch dirs {
+	case 1:
+		return false, errors.New("cannot diff a directory and a file")
+	case 2:
+		return runDirectoryDiff(os.Stdout, pflag.Arg(0), pflag.Arg(1))
+	}
+	files := make([]diffFile, 0, pflag.NArg())
+	for _, arg := range pflag.Args() {
+		readValue := readValueFromFile
+		if isGitRevisionPath(arg) {
+			readValue = readValueFromGit
+		}
+		value, err := readValue(arg)
+		if err != nil {
+			return false, err
+		}
+		files = append(files, diffFile{name: arg, value: value, exists: true})
+	}
+	differ, err := newDiffer()
+	if err != nil {
+		return false, err
+	}
+	colorOutput, err := useColor()
+	if err != nil {
+		return false, err
+	}
+	moves, text, err := flattenDiffFiles(differ, files[0], files[1])
+	if err != nil {
+		return false, err
+	}
+	differences := len(moves) > 0 || text[0] != text[1]
+	switch {
+	case *stat || *nameOnly:
+		stats, err := statDiff(moves, text, *depth)
+		if err != nil {
+			return false, err
+		}
+		if *nameOnly {
+			for _, pathStat := range stats {
+				if _, err := fmt.Println(pathStat.Path); err != nil {
+					return false, err
+				}
+			}
+			return differences, nil
+		}
+		return differences, writeStat(os.Stdout, stats, "path")
+	}
+	switch *format {
+	case "html", "json":
+		changes, err := flatjson.DiffFlat(strings.NewReader(text[0]), strings.NewReader(text[1]))
+		if err != nil {
+			return false, err
+		}
+		if *format == "html" {
+			return differences, writeHTMLReport(os.Stdout, files[0].name, files[1].name, changes, moves)
+		}
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return differences, e.Encode(append(append([]flatjson.Change{}, moves...), changes...))
+	case "unified":
+		return differences, writeUnifiedDiff(os.Stdout, files[0], files[1], moves, text, colorOutput)
+	default:
+		return false, fmt.Errorf("%s: invalid --format value", *format)
+	}
+}
+
+// statDiff returns the statistics of the changes between the flat writes in
+// text and the moved array elements in moves, in path order. With --stat,
+// changes are grouped by top-level subtree unless --depth is given.
+func statDiff(moves []flatjson.Change, text [2]string, depth int) ([]flatjson.PathStat, error) {
+	changes, err := flatjson.DiffFlat(strings.NewReader(text[0]), strings.NewReader(text[1]))
+	if err != nil {
+		return nil, err
+	}
+	if *stat && !pflag.CommandLine.Changed("depth") {
+		depth = 1
+	}
+	changes = append(changes, moves...)
+	if err := flatjson.SortChanges(changes); err != nil {
+		return nil, err
+	}
+	return flatjson.StatChanges(changes, depth)
+}
+
+// writeUnifiedDiff writes the moved array elements in moves followed by the
+// unified diff of text, the flat writes of a and b, to w.
+func writeUnifiedDiff(w io.Writer, a, b diffFile, moves []flatjson.Change, text [2]string, colorOutput bool) error {
+	for _, move := range moves {
+		line := move.String()
+		if colorOutput {
+			line = "\x1b[1m" + line + "\x1b[m"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
 			return err
 		}
-		text = append(text, sb.String())
 	}
-	diff := difflib.UnifiedDiff{
-		A:        difflib.SplitLines(text[0]),
-		B:        difflib.SplitLines(text[1]),
-		FromFile: pflag.Arg(0),
-		ToFile:   pflag.Arg(1),
-		Context:  *context,
+	linesA, linesB := flatjson.SplitLines(text[0]), flatjson.SplitLines(text[1])
+	// Like diff(1), a file that does not exist has no lines, and the other file
+	// has only its own lines, without the empty final line that SplitLines
+	// appends.
+	switch {
+	case !a.exists:
+		linesA, linesB = nil, flatjson.SplitLines(strings.TrimSuffix(text[1], "\n"))
+	case !b.exists:
+		linesA, linesB = flatjson.SplitLines(strings.TrimSuffix(text[0], "\n")), nil
+	}
+	diff := flatjson.UnifiedDiff{
+		A:          linesA,
+		B:          linesB,
+		FromFile:   a.name,
+		ToFile:     b.name,
+		Context:    *context,
+		Color:      colorOutput,
+		SideBySide: *sideBySide,
+		Width:      *width,
+		WordDiff:   *wordDiff,
+	}
+	return flatjson.WriteUnifiedDiff(w, diff)
+}
+
+// flattenerOptions returns the options for Flattener set on the command line.
+func flattenerOptions() []flatjson.FlattenerOption {
+	options := []flatjson.FlattenerOption{
+		flatjson.WithPrefix(*prefix),
+		flatjson.WithSuffix(*suffix),
+		flatjson.WithMaxDepth(*maxDepth),
+	}
+	if *embeddedJSON {
+		options = append(options, flatjson.WithEmbeddedJSON())
+	}
+	if *annotations != "none" {
+		options = append(options, flatjson.WithAnnotations(flatjson.AnnotationStyle(*annotations)))
 	}
-	return difflib.WriteUnifiedDiff(os.Stdout, diff)
+	if *omitContainers {
+		options = append(options, flatjson.WithOmitContainers())
+	}
+	if *templateLiterals {
+		options = append(options, flatjson.WithTemplateLiterals())
+	}
+	if *utf8 {
+		options = append(options, flatjson.WithUTF8())
+	}
+	return options
 }
 
 // runForward flat writes the JSON in each file specified on the command line.
 // If no files are specified then the JSON is read from stdin.
 func runForward() error {
-	f := flatjson.NewFlattener(os.Stdout, flatjson.WithPrefix(*prefix), flatjson.WithSuffix(*suffix))
+	f := flatjson.NewFlattener(os.Stdout, append(flattenerOptions(),
+		flatjson.WithUnorderedArrays(*unorderedArrays...),
+	)...)
 	if len(pflag.Args()) == 0 {
 		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
 			return err
 		}
-		return f.WriteValues(data)
+		value, err := flatjson.UnmarshalInput(data, parseInputFormat())
+		if err != nil {
+			return err
+		}
+		return f.WriteValue(value)
 	}
 	for _, arg := range pflag.Args() {
-		if err := writeValuesFromFile(f, arg); err != nil {
+		value, err := readValueFromFile(arg)
+		if err != nil {
+			return err
+		}
+		if err := f.WriteValue(value); err != nil {
 			return err
 		}
 	}
@@ -80,56 +387,149 @@ func runForward() error {
 
 // runReverse reads flat JSON from each file specified on the command line and
 // writes the resulting JSON to stdout. If no files are specified then the flat
-// JSON is read from stdin.
+// JSON is read from stdin. Input in other formats is merged as if it had been
+// flattened.
 func runReverse() error {
-	d := flatjson.NewDeepener()
+	var options []flatjson.DeepenerOption
+	if *strict {
+		options = append(options, flatjson.DeepenerStrict())
+	}
+	d := flatjson.NewDeepener(options...)
 	var root interface{}
 	if len(pflag.Args()) == 0 {
-		var err error
-		root, err = d.MergeValues(root, os.Stdin)
+		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
 			return err
 		}
+		if root, err = mergeValues(d, root, data); err != nil {
+			return err
+		}
 	} else {
 		for _, arg := range pflag.Args() {
-			var err error
-			root, err = mergeValuesFromFile(d, root, arg)
+			data, err := os.ReadFile(arg)
 			if err != nil {
 				return err
 			}
+			if root, err = mergeValues(d, root, data); err != nil {
+				return err
+			}
 		}
 	}
 	return json.NewEncoder(os.Stdout).Encode(root)
 }
 
-// writeValuesFromFile reads JSON from the file named filename and writes the
-// flattened values to f.
-func writeValuesFromFile(f *flatjson.Flattener, filename string) error {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return err
+// writeStat writes stats to w like git's --stat option, followed by a summary
+// line that counts stats as noun.
+func writeStat(w io.Writer, stats []flatjson.PathStat, noun string) error {
+	pathWidth := 0
+	for _, pathStat := range stats {
+		pathWidth = max(pathWidth, len([]rune(pathStat.Path)))
+	}
+	var total flatjson.PathStat
+	for _, pathStat := range stats {
+		line := fmt.Sprintf(" %-*s | %d", pathWidth, pathStat.Path, pathStat.Added+pathStat.Removed+pathStat.Changed+pathStat.Moved)
+		for _, count := range []struct {
+			n      int
+			symbol string
+		}{
+			{pathStat.Added, "+"},
+			{pathStat.Removed, "-"},
+			{pathStat.Changed, "~"},
+			{pathStat.Moved, ">"},
+		} {
+			if count.n > 0 {
+				line += fmt.Sprintf(" %s%d", count.symbol, count.n)
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		total.Added += pathStat.Added
+		total.Removed += pathStat.Removed
+		total.Changed += pathStat.Changed
+		total.Moved += pathStat.Moved
+	}
+	if len(stats) != 1 {
+		noun += "s"
+	}
+	_, err := fmt.Fprintf(w, " %d %s changed, %d added(+), %d removed(-), %d changed(~), %d moved(>)\n",
+		len(stats), noun, total.Added, total.Removed, total.Changed, total.Moved)
+	return err
+}
+
+// useColor returns whether to color the output. With --color=auto, output is
+// colored if stdout is a terminal and the NO_COLOR environment variable is not
+// set.
+func useColor() (bool, error) {
+	switch *color {
+	case "always":
+		return true, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		fileInfo, err := os.Stdout.Stat()
+		if err != nil {
+			return false, nil
+		}
+		return fileInfo.Mode()&os.ModeCharDevice != 0, nil
+	case "never":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: invalid --color value", *color)
 	}
-	return f.WriteValues(data)
 }
 
-func run() error {
+// run runs flatjson and returns whether differences were found.
+func run() (bool, error) {
 	if *diff && *reverse {
-		return errors.New("cannot use --diff with --reverse")
+		return false, errors.New("cannot use --diff with --reverse")
+	}
+	if *baseline != "" && (*diff || *reverse) {
+		return false, errors.New("cannot use --baseline with --diff or --reverse")
+	}
+	switch *annotations {
+	case "comment", "length", "none":
+	default:
+		return false, fmt.Errorf("%s: invalid --annotations value", *annotations)
 	}
 	switch {
+	case pflag.Arg(0) == "apply-diff":
+		return false, runApplyDiff(pflag.Args()[1:])
+	case pflag.Arg(0) == "del":
+		return false, runDel(pflag.Args()[1:])
+	case pflag.Arg(0) == "get":
+		return false, runGet(pflag.Args()[1:])
+	case pflag.Arg(0) == "log":
+		return false, runLog(pflag.Args()[1:])
+	case pflag.Arg(0) == "set":
+		return false, runSet(pflag.Args()[1:])
+	case *baseline != "":
+		return runBaseline()
 	case *diff:
 		return runDiff()
 	case *reverse:
-		return runReverse()
+		return false, runReverse()
 	default:
-		return runForward()
+		return false, runForward()
 	}
 }
 
+// main exits with status 0 on success. Like diff(1), with --diff or --baseline
+// it exits with status 1 if the files differ and status 2 on error.
 func main() {
+	pflag.Lookup("color").NoOptDefVal = "always"
+	pflag.Lookup("redact").NoOptDefVal = "***"
 	pflag.Parse()
-	if err := run(); err != nil {
+	differences, err := run()
+	switch {
+	case err != nil && (*diff || *baseline != ""):
 		fmt.Printf("%v\n", err)
+		os.Exit(2)
+	case err != nil:
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	case differences:
 		os.Exit(1)
 	}
 }
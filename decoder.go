@@ -18,16 +18,99 @@ func NewDecoder(r io.Reader) *Decoder {
 	}
 }
 
-// Decode decodes a value, merging it into root.
+// Decode decodes a value, merging it into root. Objects and arrays whose
+// declarations are marked with a `/* json */` comment are converted back into
+// strings of embedded JSON.
 func (d *Decoder) Decode(root interface{}) (interface{}, error) {
 	assignments, err := newParser(d.r).parseAssignments()
 	if err != nil {
 		return nil, err
 	}
+	var embeddedJSONs []*embeddedJSON
 	for _, assignment := range assignments {
-		root = recursiveMerge(root, assignment.properties, assignment.value)
+		if embeddedJSON := newEmbeddedJSON(assignment); embeddedJSON != nil {
+			embeddedJSONs = append(embeddedJSONs, embeddedJSON)
+		}
+		root = applyAssignment(root, assignment, recursiveMerge)
+	}
+	return stringifyEmbeddedJSON(root, embeddedJSONs)
+}
+
+// applyAssignment applies assignment to root using merge to set values and
+// returns the new root. Deleting a missing value does nothing. Appending to a
+// value that is not an array replaces it with a new array. Annotations of the
+// lengths of arrays, like `root.items.length = 3;`, are ignored.
+func applyAssignment(root interface{}, assignment *assignment, merge func(interface{}, []interface{}, interface{}) interface{}) interface{} {
+	if isLengthAnnotation(root, assignment) {
+		return root
+	}
+	switch assignment.op {
+	case assignmentDelete:
+		return recursiveDelete(root, assignment.properties)
+	case assignmentAppend:
+		array, _ := lookupValue(root, assignment.properties).([]interface{})
+		array = append(array[:len(array):len(array)], assignment.value)
+		return merge(root, assignment.properties, array)
+	default:
+		return merge(root, assignment.properties, assignment.value)
+	}
+}
+
+// lookupValue returns the value at properties in root, or nil if there is no
+// such value.
+func lookupValue(root interface{}, properties []interface{}) interface{} {
+	for _, property := range properties {
+		switch property := property.(type) {
+		case int:
+			array, ok := root.([]interface{})
+			if !ok || property >= len(array) {
+				return nil
+			}
+			root = array[property]
+		case string:
+			object, ok := root.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			root = object[property]
+		}
+	}
+	return root
+}
+
+// recursiveDelete deletes the value at properties in root and returns the new
+// root. Deleting an element of an array shifts the following elements down.
+func recursiveDelete(root interface{}, properties []interface{}) interface{} {
+	if len(properties) == 0 {
+		return nil
+	}
+	switch property := properties[0].(type) {
+	case int:
+		array, ok := root.([]interface{})
+		if !ok || property >= len(array) {
+			return root
+		}
+		if len(properties) == 1 {
+			return append(array[:property], array[property+1:]...)
+		}
+		array[property] = recursiveDelete(array[property], properties[1:])
+		return array
+	case string:
+		object, ok := root.(map[string]interface{})
+		if !ok {
+			return root
+		}
+		if len(properties) == 1 {
+			delete(object, property)
+			return object
+		}
+		if value, ok := object[property]; ok {
+			object[property] = recursiveDelete(value, properties[1:])
+		}
+		return object
+	default:
+		panic(fmt.Sprintf("unexpected property %v (%T)", property, property))
 	}
-	return root, nil
 }
 
 func recursiveMerge(root interface{}, properties []interface{}, value interface{}) interface{} {
@@ -6,23 +6,67 @@ import (
 )
 
 // A Deepener converts flat JSON into a JSON object.
-type Deepener struct{}
+type Deepener struct {
+	strict bool
+}
+
+// A DeepenerOption sets an option on a Deepener.
+type DeepenerOption func(*Deepener)
 
 // NewDeepener returns a new Deepener.
-func NewDeepener() *Deepener {
-	return &Deepener{}
+func NewDeepener(options ...DeepenerOption) *Deepener {
+	d := &Deepener{}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// DeepenerStrict makes a Deepener check annotations of the sizes of arrays and
+// objects, like `root.items.length = 3;` and `root.items = []; /* 3 items */`,
+// against the values that it reconstructs.
+func DeepenerStrict() DeepenerOption {
+	return func(d *Deepener) {
+		d.strict = true
+	}
 }
 
-// MergeValues merges values read from r into root.
+// MergeValues merges values read from r into root. Delete statements, like
+// `delete root.a;`, remove values and append statements, like
+// `root.items[] = 1;`, append values to arrays. Annotations of the lengths of
+// arrays, like `root.items.length = 3;`, are ignored unless the Deepener is
+// strict, in which case an error is returned if any annotation does not match
+// the merged values. Objects and arrays whose declarations are marked with a
+// `/* json */` comment, as written by a Flattener with WithEmbeddedJSON, are
+// converted back into strings of embedded JSON.
 func (d *Deepener) MergeValues(root interface{}, r io.Reader) (interface{}, error) {
 	assignments, err := newParser(r).parseAssignments()
 	if err != nil {
 		return nil, err
 	}
+	var annotations []*annotation
+	var embeddedJSONs []*embeddedJSON
 	for _, assignment := range assignments {
-		root = d.recursiveMerge(root, assignment.properties, assignment.value)
+		if embeddedJSON := newEmbeddedJSON(assignment); embeddedJSON != nil {
+			embeddedJSONs = append(embeddedJSONs, embeddedJSON)
+		}
+		if d.strict {
+			annotation, err := parseAnnotation(root, assignment)
+			if err != nil {
+				return nil, err
+			}
+			if annotation != nil {
+				annotations = append(annotations, annotation)
+			}
+		}
+		root = applyAssignment(root, assignment, d.recursiveMerge)
+	}
+	for _, annotation := range annotations {
+		if err := annotation.check(root); err != nil {
+			return nil, err
+		}
 	}
-	return root, nil
+	return stringifyEmbeddedJSON(root, embeddedJSONs)
 }
 
 func (d *Deepener) recursiveMerge(root interface{}, properties []interface{}, value interface{}) interface{} {
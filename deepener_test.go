@@ -0,0 +1,135 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestMergeValuesStatements(t *testing.T) {
+	for i, tc := range []struct {
+		root     string
+		s        string
+		expected interface{}
+	}{
+		{
+			root: `{"a":{"b":true,"c":false}}`,
+			s:    "delete root.a.b;\n",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"c": false},
+			},
+		},
+		{
+			root: `{"a":["x","y","z"]}`,
+			s:    "delete root.a[1];\n",
+			expected: map[string]interface{}{
+				"a": []interface{}{"x", "z"},
+			},
+		},
+		{
+			root:     `{"a":true}`,
+			s:        "delete root.b.c;\ndelete root.a[0];\n",
+			expected: map[string]interface{}{"a": true},
+		},
+		{
+			root:     `{"a":true}`,
+			s:        "delete root;\n",
+			expected: nil,
+		},
+		{
+			root: `{"items":["x"]}`,
+			s:    "root.items[] = \"y\";\nroot.items[] = {};\nroot.items[2].a = 1;\n",
+			expected: map[string]interface{}{
+				"items": []interface{}{"x", "y", map[string]interface{}{"a": json.Number("1")}},
+			},
+		},
+		{
+			root: `{"items":true}`,
+			s:    "root.items[] = 1;\nroot.other[] = 2;\n",
+			expected: map[string]interface{}{
+				"items": []interface{}{json.Number("1")},
+				"other": []interface{}{json.Number("2")},
+			},
+		},
+		{
+			root: `{}`,
+			s:    "root.delete = 1;\n",
+			expected: map[string]interface{}{
+				"delete": json.Number("1"),
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			root, err := UnmarshalInput([]byte(tc.root), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener().MergeValues(root, strings.NewReader(tc.s))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			root, err = UnmarshalInput([]byte(tc.root), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err = NewDecoder(strings.NewReader(tc.s)).Decode(root)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestMergeValuesStrict(t *testing.T) {
+	for i, tc := range []struct {
+		s           string
+		expected    interface{}
+		expectedErr string
+	}{
+		{
+			s:        "root = [];\nroot[0] = 1;\nroot.length = 1;\n",
+			expected: []interface{}{json.Number("1")},
+		},
+		{
+			s:        "root = {}; /* 1 property */\nroot.a = []; /* 0 items */\n",
+			expected: map[string]interface{}{"a": []interface{}{}},
+		},
+		{
+			s:        "root = {};\nroot.length = 1;\n",
+			expected: map[string]interface{}{"length": json.Number("1")},
+		},
+		{
+			s:        "root = {}; /* not an annotation */\n",
+			expected: map[string]interface{}{},
+		},
+		{
+			s:           "root = {};\nroot.a = [];\nroot.a[0] = 1;\nroot.a.length = 3;\n",
+			expectedErr: "root.a: declared size 3, found 1",
+		},
+		{
+			s:           "root = []; /* 2 items */\nroot[0] = 1;\n",
+			expectedErr: "root: declared size 2, found 1",
+		},
+		{
+			s:           "root = {}; /* 2 items */\n",
+			expectedErr: "root: declared an array, found object",
+		},
+		{
+			s:           "root = []; /* 1 property */\ndelete root;\n",
+			expectedErr: "root: declared an object, found null",
+		},
+		{
+			s:           "root = [];\nroot.length = \"1\";\n",
+			expectedErr: "root.length: invalid length",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := NewDeepener(DeepenerStrict()).MergeValues(nil, strings.NewReader(tc.s))
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				_, err = NewDeepener().MergeValues(nil, strings.NewReader(tc.s))
+				assert.NoError(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
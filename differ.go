@@ -0,0 +1,641 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var numberRegexp = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][-+]?[0-9]+)?$`)
+
+// A ChangeOp is the kind of a Change.
+type ChangeOp string
+
+// ChangeOps.
+const (
+	ChangeAdd     ChangeOp = "add"
+	ChangeRemove  ChangeOp = "remove"
+	ChangeReplace ChangeOp = "replace"
+	ChangeMove    ChangeOp = "move"
+)
+
+// A Change is a single difference between two JSON values. Path is the path
+// of the value in the new value, except for removals where it is the path of
+// the value in the old value. From is only set for moves and is the path of
+// the element in the old value.
+type Change struct {
+	Op   ChangeOp
+	Path string
+	From string
+	Old  interface{}
+	New  interface{}
+}
+
+// A Differ compares JSON values.
+type Differ struct {
+	prefix            string
+	detectMoves       bool
+	identityFields    []string
+	unorderedArrays   []string
+	numeric           bool
+	numericStrings    bool
+	absTolerance      float64
+	relTolerance      float64
+	nullEqualsMissing bool
+	ignore            []string
+	redact            bool
+	placeholder       interface{}
+	utf8              bool
+}
+
+// A DifferOption sets an option on a Differ.
+type DifferOption func(*Differ)
+
+// An elementMatch pairs an element of an old array with an element of a new
+// array. oldIndex or newIndex is -1 if the element was added or removed.
+type elementMatch struct {
+	oldIndex int
+	newIndex int
+	moved    bool
+}
+
+// NewDiffer returns a new Differ.
+func NewDiffer(options ...DifferOption) *Differ {
+	d := &Differ{
+		prefix: "root",
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Diff returns the changes that turn a into b.
+func (d *Differ) Diff(a, b interface{}) []Change {
+	a, b = d.prepare(a, b)
+	return d.diffHelper(nil, d.prefix, d.prefix, a, b)
+}
+
+// Normalize returns copies of a and b in which ignored values are dropped or
+// redacted, unordered arrays are sorted, and array elements of a are reordered
+// to match the order of b, so that a line-based diff of their flattened
+// representations shows only edits.
+func (d *Differ) Normalize(a, b interface{}) (interface{}, interface{}) {
+	a, b = d.prepare(a, b)
+	return d.normalizeHelper(a, b)
+}
+
+func (d *Differ) diffArrays(changes []Change, oldPath, newPath string, a, b []interface{}) []Change {
+	for _, match := range d.matchElements(a, b) {
+		oldElementPath := oldPath + "[" + strconv.Itoa(match.oldIndex) + "]"
+		newElementPath := newPath + "[" + strconv.Itoa(match.newIndex) + "]"
+		switch {
+		case match.newIndex == -1:
+			changes = append(changes, Change{
+				Op:   ChangeRemove,
+				Path: oldElementPath,
+				Old:  a[match.oldIndex],
+			})
+		case match.oldIndex == -1:
+			changes = append(changes, Change{
+				Op:   ChangeAdd,
+				Path: newElementPath,
+				New:  b[match.newIndex],
+			})
+		default:
+			if match.moved {
+				changes = append(changes, Change{
+					Op:   ChangeMove,
+					Path: newElementPath,
+					From: oldElementPath,
+				})
+			}
+			changes = d.diffHelper(changes, oldElementPath, newElementPath, a[match.oldIndex], b[match.newIndex])
+		}
+	}
+	return changes
+}
+
+func (d *Differ) diffHelper(changes []Change, oldPath, newPath string, a, b interface{}) []Change {
+	switch a := a.(type) {
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			return d.diffArrays(changes, oldPath, newPath, a, b)
+		}
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			return d.diffObjects(changes, oldPath, newPath, a, b)
+		}
+	default:
+		if isScalar(b) && d.scalarsEqual(a, b) {
+			return changes
+		}
+	}
+	return append(changes, Change{
+		Op:   ChangeReplace,
+		Path: newPath,
+		Old:  a,
+		New:  b,
+	})
+}
+
+func (d *Differ) diffObjects(changes []Change, oldPath, newPath string, a, b map[string]interface{}) []Change {
+	for _, property := range unionProperties(a, b) {
+		oldValue, inA := a[property]
+		newValue, inB := b[property]
+		switch {
+		case !inB && oldValue == nil && d.nullEqualsMissing:
+		case !inA && newValue == nil && d.nullEqualsMissing:
+		case !inB:
+			changes = append(changes, Change{
+				Op:   ChangeRemove,
+				Path: propertyAccessor(oldPath, property, d.utf8),
+				Old:  oldValue,
+			})
+		case !inA:
+			changes = append(changes, Change{
+				Op:   ChangeAdd,
+				Path: propertyAccessor(newPath, property, d.utf8),
+				New:  newValue,
+			})
+		default:
+			changes = d.diffHelper(changes, propertyAccessor(oldPath, property, d.utf8), propertyAccessor(newPath, property, d.utf8), oldValue, newValue)
+		}
+	}
+	return changes
+}
+
+// elementKey returns the key used to match element between arrays.
+func (d *Differ) elementKey(element interface{}) string {
+	if object, ok := element.(map[string]interface{}); ok {
+		for _, identityField := range d.identityFields {
+			if value, ok := object[identityField]; ok {
+				return "id:" + identityField + ":" + canonicalJSON(value)
+			}
+		}
+	}
+	return "content:" + canonicalJSON(element)
+}
+
+// matchElements pairs the elements of a with the elements of b. The returned
+// matches are in aligned order, i.e. in the order of b with removed elements
+// of a following their predecessor in a.
+func (d *Differ) matchElements(a, b []interface{}) []elementMatch {
+	newIndexOf := make([]int, len(a))
+	oldIndexOf := make([]int, len(b))
+	for i := range newIndexOf {
+		newIndexOf[i] = -1
+	}
+	for j := range oldIndexOf {
+		oldIndexOf[j] = -1
+	}
+
+	var moved []bool
+	if d.detectMoves {
+		// Match elements with equal keys in order.
+		oldIndexesByKey := make(map[string][]int)
+		for i, element := range a {
+			key := d.elementKey(element)
+			oldIndexesByKey[key] = append(oldIndexesByKey[key], i)
+		}
+		var matchedOldIndexes []int
+		var matchedNewIndexes []int
+		for j, element := range b {
+			key := d.elementKey(element)
+			if oldIndexes := oldIndexesByKey[key]; len(oldIndexes) > 0 {
+				oldIndexesByKey[key] = oldIndexes[1:]
+				newIndexOf[oldIndexes[0]] = j
+				oldIndexOf[j] = oldIndexes[0]
+				matchedOldIndexes = append(matchedOldIndexes, oldIndexes[0])
+				matchedNewIndexes = append(matchedNewIndexes, j)
+			}
+		}
+
+		// Matched elements that are not in the longest increasing subsequence
+		// of old indexes have moved.
+		moved = make([]bool, len(b))
+		for _, j := range matchedNewIndexes {
+			moved[j] = true
+		}
+		for _, k := range longestIncreasingSubsequence(matchedOldIndexes) {
+			moved[matchedNewIndexes[k]] = false
+		}
+
+		// Pair remaining elements at the same index.
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if newIndexOf[i] == -1 && oldIndexOf[i] == -1 {
+				newIndexOf[i] = i
+				oldIndexOf[i] = i
+			}
+		}
+	} else {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			newIndexOf[i] = i
+			oldIndexOf[i] = i
+		}
+	}
+
+	matches := make([]elementMatch, 0, len(a)+len(b))
+	keys := make([]int, 0, len(a)+len(b))
+	key := -1
+	for i, j := range newIndexOf {
+		if j != -1 {
+			key = j
+		}
+		matches = append(matches, elementMatch{
+			oldIndex: i,
+			newIndex: j,
+			moved:    j != -1 && moved != nil && moved[j],
+		})
+		keys = append(keys, key)
+	}
+	for j, i := range oldIndexOf {
+		if i == -1 {
+			matches = append(matches, elementMatch{
+				oldIndex: -1,
+				newIndex: j,
+			})
+			keys = append(keys, j)
+		}
+	}
+	sort.Sort(&alignedMatches{
+		matches: matches,
+		keys:    keys,
+	})
+	return matches
+}
+
+func (d *Differ) normalizeHelper(a, b interface{}) (interface{}, interface{}) {
+	switch a := a.(type) {
+	case []interface{}:
+		arrayB, ok := b.([]interface{})
+		if !ok {
+			return a, b
+		}
+		b := arrayB
+		newA := make([]interface{}, 0, len(a))
+		newB := make([]interface{}, len(b))
+		copy(newB, b)
+		for _, match := range d.matchElements(a, b) {
+			switch {
+			case match.oldIndex == -1:
+			case match.newIndex == -1:
+				newA = append(newA, a[match.oldIndex])
+			default:
+				elementA, elementB := d.normalizeHelper(a[match.oldIndex], b[match.newIndex])
+				newA = append(newA, elementA)
+				newB[match.newIndex] = elementB
+			}
+		}
+		return newA, newB
+	case map[string]interface{}:
+		objectB, ok := b.(map[string]interface{})
+		if !ok {
+			return a, b
+		}
+		b := objectB
+		newA := make(map[string]interface{}, len(a))
+		newB := make(map[string]interface{}, len(b))
+		for _, property := range unionProperties(a, b) {
+			valueA, inA := a[property]
+			valueB, inB := b[property]
+			switch {
+			case !inB && valueA == nil && d.nullEqualsMissing:
+			case !inA && valueB == nil && d.nullEqualsMissing:
+			case !inB:
+				newA[property] = valueA
+			case !inA:
+				newB[property] = valueB
+			default:
+				newA[property], newB[property] = d.normalizeHelper(valueA, valueB)
+			}
+		}
+		return newA, newB
+	default:
+		if isScalar(b) && d.scalarsEqual(a, b) {
+			return a, a
+		}
+		return a, b
+	}
+}
+
+// number returns value as a number, if possible.
+func (d *Differ) number(value interface{}) (*big.Rat, bool) {
+	switch value := value.(type) {
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(value), true
+	case int:
+		return new(big.Rat).SetInt64(int64(value)), true
+	case json.Number:
+		return new(big.Rat).SetString(value.String())
+	case string:
+		if !d.numericStrings || !numberRegexp.MatchString(value) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(value)
+	default:
+		return nil, false
+	}
+}
+
+// scalarsEqual returns whether the scalars a and b are equal.
+func (d *Differ) scalarsEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if !d.numeric {
+		return false
+	}
+	x, ok := d.number(a)
+	if !ok {
+		return false
+	}
+	y, ok := d.number(b)
+	if !ok {
+		return false
+	}
+	if x.Cmp(y) == 0 {
+		return true
+	}
+	if d.absTolerance == 0 && d.relTolerance == 0 {
+		return false
+	}
+	fx, _ := x.Float64()
+	fy, _ := y.Float64()
+	delta := math.Abs(fx - fy)
+	return delta <= d.absTolerance || delta <= d.relTolerance*math.Max(math.Abs(fx), math.Abs(fy))
+}
+
+// ignorePaths returns a copy of value in which values whose paths match
+// d.ignore are dropped or redacted. Dropped array elements are replaced by
+// null so that the paths of later elements are unchanged. It returns false if
+// value itself is dropped.
+func (d *Differ) ignorePaths(path string, value interface{}) (interface{}, bool) {
+	if matchAnyPattern(d.ignore, path) {
+		if d.redact {
+			return d.placeholder, true
+		}
+		return nil, false
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		array := make([]interface{}, len(value))
+		for i, element := range value {
+			array[i], _ = d.ignorePaths(path+"["+strconv.Itoa(i)+"]", element)
+		}
+		return array, true
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for property, propertyValue := range value {
+			if propertyValue, ok := d.ignorePaths(propertyAccessor(path, property, d.utf8), propertyValue); ok {
+				object[property] = propertyValue
+			}
+		}
+		return object, true
+	default:
+		return value, true
+	}
+}
+
+// prepare returns copies of a and b with ignored values dropped or redacted
+// and unordered arrays sorted.
+func (d *Differ) prepare(a, b interface{}) (interface{}, interface{}) {
+	if len(d.ignore) > 0 {
+		a, _ = d.ignorePaths(d.prefix, a)
+		b, _ = d.ignorePaths(d.prefix, b)
+	}
+	if len(d.unorderedArrays) > 0 {
+		a = sortUnorderedArrays(d.prefix, a, d.unorderedArrays, d.utf8)
+		b = sortUnorderedArrays(d.prefix, b, d.unorderedArrays, d.utf8)
+	}
+	return a, b
+}
+
+// String returns a human-readable representation of c.
+func (c Change) String() string {
+	switch c.Op {
+	case ChangeMove:
+		return string(c.Op) + " " + c.From + " -> " + c.Path
+	default:
+		return string(c.Op) + " " + c.Path
+	}
+}
+
+// MarshalJSON implements encoding/json.Marshaler. A Change is encoded as an
+// object with op and path properties, a from property for moves, an old
+// property unless the value was added, and a new property unless the value was
+// removed.
+func (c Change) MarshalJSON() ([]byte, error) {
+	type jsonChange struct {
+		Op   ChangeOp        `json:"op"`
+		Path string          `json:"path"`
+		From string          `json:"from,omitempty"`
+		Old  json.RawMessage `json:"old,omitempty"`
+		New  json.RawMessage `json:"new,omitempty"`
+	}
+	result := jsonChange{
+		Op:   c.Op,
+		Path: c.Path,
+		From: c.From,
+	}
+	var err error
+	if c.Op == ChangeRemove || c.Op == ChangeReplace {
+		if result.Old, err = json.Marshal(c.Old); err != nil {
+			return nil, err
+		}
+	}
+	if c.Op == ChangeAdd || c.Op == ChangeReplace {
+		if result.New, err = json.Marshal(c.New); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(result)
+}
+
+// DifferDetectMoves enables the detection of moved array elements. Elements
+// are matched by content or, for objects, by the value of the first of
+// identityFields that they contain.
+func DifferDetectMoves(identityFields ...string) DifferOption {
+	return func(d *Differ) {
+		d.detectMoves = true
+		d.identityFields = identityFields
+	}
+}
+
+// DifferAbsTolerance sets the absolute tolerance within which numbers are
+// equal. It implies DifferNumericEquality.
+func DifferAbsTolerance(tolerance float64) DifferOption {
+	return func(d *Differ) {
+		d.numeric = true
+		d.absTolerance = tolerance
+	}
+}
+
+// DifferIgnore sets the patterns of paths of values that are dropped before
+// comparison.
+func DifferIgnore(patterns ...string) DifferOption {
+	return func(d *Differ) {
+		d.ignore = patterns
+	}
+}
+
+// DifferNullEqualsMissing sets whether a property with a null value is equal
+// to a missing property.
+func DifferNullEqualsMissing() DifferOption {
+	return func(d *Differ) {
+		d.nullEqualsMissing = true
+	}
+}
+
+// DifferNumericEquality sets whether numbers are compared by value, so that,
+// for example, 1, 1.0, and 1e0 are equal.
+func DifferNumericEquality() DifferOption {
+	return func(d *Differ) {
+		d.numeric = true
+	}
+}
+
+// DifferNumericStrings sets whether strings that contain numbers are compared
+// by value with numbers and other such strings. It implies
+// DifferNumericEquality.
+func DifferNumericStrings() DifferOption {
+	return func(d *Differ) {
+		d.numeric = true
+		d.numericStrings = true
+	}
+}
+
+// DifferPrefix sets the prefix of paths.
+func DifferPrefix(prefix string) DifferOption {
+	return func(d *Differ) {
+		d.prefix = prefix
+	}
+}
+
+// DifferRedact sets that values with ignored paths are replaced by
+// placeholder instead of being dropped, so that their addition and removal
+// remain visible.
+func DifferRedact(placeholder interface{}) DifferOption {
+	return func(d *Differ) {
+		d.redact = true
+		d.placeholder = placeholder
+	}
+}
+
+// DifferRelTolerance sets the tolerance, relative to the larger magnitude,
+// within which numbers are equal. It implies DifferNumericEquality.
+func DifferRelTolerance(tolerance float64) DifferOption {
+	return func(d *Differ) {
+		d.numeric = true
+		d.relTolerance = tolerance
+	}
+}
+
+// DifferUnorderedArrays sets the patterns of paths of arrays whose elements are
+// compared as sets, i.e. are sorted by their flattened representation before
+// they are compared.
+func DifferUnorderedArrays(patterns ...string) DifferOption {
+	return func(d *Differ) {
+		d.unorderedArrays = patterns
+	}
+}
+
+// DifferUTF8 writes the paths of property names that are non-ASCII identifiers
+// with dot notation, like WithUTF8. Patterns are matched against paths written
+// this way.
+func DifferUTF8() DifferOption {
+	return func(d *Differ) {
+		d.utf8 = true
+	}
+}
+
+// alignedMatches sorts elementMatches by key, breaking ties by old index.
+type alignedMatches struct {
+	matches []elementMatch
+	keys    []int
+}
+
+func (m *alignedMatches) Len() int { return len(m.matches) }
+
+func (m *alignedMatches) Less(i, j int) bool {
+	if m.keys[i] != m.keys[j] {
+		return m.keys[i] < m.keys[j]
+	}
+	return m.matches[i].oldIndex < m.matches[j].oldIndex
+}
+
+func (m *alignedMatches) Swap(i, j int) {
+	m.matches[i], m.matches[j] = m.matches[j], m.matches[i]
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+}
+
+// canonicalJSON returns a canonical JSON encoding of value.
+func canonicalJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+	return string(data)
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+// longestIncreasingSubsequence returns the indexes into values of a longest
+// strictly increasing subsequence of values.
+func longestIncreasingSubsequence(values []int) []int {
+	var tails []int
+	predecessors := make([]int, len(values))
+	for i, value := range values {
+		k := sort.Search(len(tails), func(k int) bool {
+			return values[tails[k]] >= value
+		})
+		if k > 0 {
+			predecessors[i] = tails[k-1]
+		} else {
+			predecessors[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	result := make([]int, len(tails))
+	if len(tails) == 0 {
+		return result
+	}
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, predecessors[k] {
+		result[i] = k
+	}
+	return result
+}
+
+// unionProperties returns the sorted union of the properties of a and b.
+func unionProperties(a, b map[string]interface{}) []string {
+	properties := make([]string, 0, len(a)+len(b))
+	for property := range a {
+		properties = append(properties, property)
+	}
+	for property := range b {
+		if _, ok := a[property]; !ok {
+			properties = append(properties, property)
+		}
+	}
+	sort.Strings(properties)
+	return properties
+}
@@ -0,0 +1,323 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestDiffer(t *testing.T) {
+	for i, tc := range []struct {
+		options  []DifferOption
+		a        interface{}
+		b        interface{}
+		expected []Change
+	}{
+		{
+			a: map[string]interface{}{},
+			b: map[string]interface{}{},
+		},
+		{
+			a: map[string]interface{}{"a": 1.0, "b": true},
+			b: map[string]interface{}{"a": 2.0, "c": nil},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.a", Old: 1.0, New: 2.0},
+				{Op: ChangeRemove, Path: "root.b", Old: true},
+				{Op: ChangeAdd, Path: "root.c"},
+			},
+		},
+		{
+			a: map[string]interface{}{"a": []interface{}{}},
+			b: map[string]interface{}{"a": map[string]interface{}{}},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.a", Old: []interface{}{}, New: map[string]interface{}{}},
+			},
+		},
+		{
+			a: []interface{}{"x", "y", "z"},
+			b: []interface{}{"z", "x", "y"},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[0]", Old: "x", New: "z"},
+				{Op: ChangeReplace, Path: "root[1]", Old: "y", New: "x"},
+				{Op: ChangeReplace, Path: "root[2]", Old: "z", New: "y"},
+			},
+		},
+		{
+			options: []DifferOption{DifferDetectMoves()},
+			a:       []interface{}{"x", "y", "z"},
+			b:       []interface{}{"z", "x", "y"},
+			expected: []Change{
+				{Op: ChangeMove, Path: "root[0]", From: "root[2]"},
+			},
+		},
+		{
+			options: []DifferOption{DifferDetectMoves()},
+			a:       []interface{}{"x", "y", "z"},
+			b:       []interface{}{"w", "x", "y", "z"},
+			expected: []Change{
+				{Op: ChangeAdd, Path: "root[0]", New: "w"},
+			},
+		},
+		{
+			options: []DifferOption{DifferDetectMoves()},
+			a:       []interface{}{"x", "y", "z"},
+			b:       []interface{}{"z", "y"},
+			expected: []Change{
+				{Op: ChangeRemove, Path: "root[0]", Old: "x"},
+				{Op: ChangeMove, Path: "root[0]", From: "root[2]"},
+			},
+		},
+		{
+			options: []DifferOption{DifferDetectMoves()},
+			a:       []interface{}{"x", "y"},
+			b:       []interface{}{"x", "w"},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[1]", Old: "y", New: "w"},
+			},
+		},
+		{
+			options: []DifferOption{DifferDetectMoves("name")},
+			a: []interface{}{
+				map[string]interface{}{"name": "a", "value": 1.0},
+				map[string]interface{}{"name": "b", "value": 2.0},
+			},
+			b: []interface{}{
+				map[string]interface{}{"name": "b", "value": 3.0},
+				map[string]interface{}{"name": "a", "value": 1.0},
+			},
+			expected: []Change{
+				{Op: ChangeMove, Path: "root[0]", From: "root[1]"},
+				{Op: ChangeReplace, Path: "root[0].value", Old: 2.0, New: 3.0},
+			},
+		},
+		{
+			options: []DifferOption{DifferUnorderedArrays("root.tags")},
+			a: map[string]interface{}{
+				"tags":  []interface{}{"a", "b", "c"},
+				"items": []interface{}{"a", "b"},
+			},
+			b: map[string]interface{}{
+				"tags":  []interface{}{"c", "a", "b"},
+				"items": []interface{}{"b", "a"},
+			},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.items[0]", Old: "a", New: "b"},
+				{Op: ChangeReplace, Path: "root.items[1]", Old: "b", New: "a"},
+			},
+		},
+		{
+			a: []interface{}{json.Number("1"), json.Number("1.0"), "1", nil},
+			b: []interface{}{json.Number("1.0"), json.Number("1e0"), json.Number("1"), json.Number("1")},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[0]", Old: json.Number("1"), New: json.Number("1.0")},
+				{Op: ChangeReplace, Path: "root[1]", Old: json.Number("1.0"), New: json.Number("1e0")},
+				{Op: ChangeReplace, Path: "root[2]", Old: "1", New: json.Number("1")},
+				{Op: ChangeReplace, Path: "root[3]", Old: nil, New: json.Number("1")},
+			},
+		},
+		{
+			options: []DifferOption{DifferNumericEquality()},
+			a:       []interface{}{json.Number("1"), json.Number("1.0"), "1", nil},
+			b:       []interface{}{json.Number("1.0"), json.Number("1e0"), json.Number("1"), json.Number("1")},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[2]", Old: "1", New: json.Number("1")},
+				{Op: ChangeReplace, Path: "root[3]", Old: nil, New: json.Number("1")},
+			},
+		},
+		{
+			options: []DifferOption{DifferNumericStrings()},
+			a:       []interface{}{"1", "1.0", "x", "0x1"},
+			b:       []interface{}{json.Number("1"), "1e0", "x", json.Number("1")},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[3]", Old: "0x1", New: json.Number("1")},
+			},
+		},
+		{
+			options: []DifferOption{DifferAbsTolerance(0.01)},
+			a:       []interface{}{json.Number("1"), json.Number("100")},
+			b:       []interface{}{json.Number("1.005"), json.Number("100.5")},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[1]", Old: json.Number("100"), New: json.Number("100.5")},
+			},
+		},
+		{
+			options: []DifferOption{DifferRelTolerance(0.01)},
+			a:       []interface{}{json.Number("1"), json.Number("100")},
+			b:       []interface{}{json.Number("1.05"), json.Number("100.5")},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root[0]", Old: json.Number("1"), New: json.Number("1.05")},
+			},
+		},
+		{
+			options: []DifferOption{DifferNullEqualsMissing()},
+			a:       map[string]interface{}{"a": nil, "b": 1.0},
+			b:       map[string]interface{}{"b": 1.0, "c": nil, "d": false},
+			expected: []Change{
+				{Op: ChangeAdd, Path: "root.d", New: false},
+			},
+		},
+		{
+			options: []DifferOption{DifferIgnore("root.metadata.*Time", "root.items[*].uid")},
+			a: map[string]interface{}{
+				"metadata": map[string]interface{}{"creationTime": "1", "name": "a"},
+				"items":    []interface{}{map[string]interface{}{"uid": "1", "x": 1.0}},
+			},
+			b: map[string]interface{}{
+				"metadata": map[string]interface{}{"creationTime": "2", "updateTime": "3", "name": "b"},
+				"items":    []interface{}{map[string]interface{}{"uid": "2", "x": 1.0}},
+			},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.metadata.name", Old: "a", New: "b"},
+			},
+		},
+		{
+			options: []DifferOption{DifferIgnore("root.items[0]")},
+			a:       map[string]interface{}{"items": []interface{}{"a", "b", "c"}},
+			b:       map[string]interface{}{"items": []interface{}{"x", "b", "d"}},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.items[2]", Old: "c", New: "d"},
+			},
+		},
+		{
+			options: []DifferOption{DifferIgnore("root.*Time"), DifferRedact("<redacted>")},
+			a:       map[string]interface{}{"creationTime": "1"},
+			b:       map[string]interface{}{"creationTime": "2", "updateTime": "3"},
+			expected: []Change{
+				{Op: ChangeAdd, Path: "root.updateTime", New: "<redacted>"},
+			},
+		},
+		{
+			a: map[string]interface{}{"données": map[string]interface{}{"t": []interface{}{1.0}, "x": "a"}},
+			b: map[string]interface{}{"données": map[string]interface{}{"t": []interface{}{2.0}, "x": "b"}},
+			expected: []Change{
+				{Op: ChangeReplace, Path: `root["données"].t[0]`, Old: 1.0, New: 2.0},
+				{Op: ChangeReplace, Path: `root["données"].x`, Old: "a", New: "b"},
+			},
+		},
+		{
+			options: []DifferOption{DifferIgnore("root.données.x"), DifferUnorderedArrays("root.données.t"), DifferUTF8()},
+			a:       map[string]interface{}{"données": map[string]interface{}{"t": []interface{}{1.0, 2.0}, "x": "a"}},
+			b:       map[string]interface{}{"données": map[string]interface{}{"t": []interface{}{3.0, 1.0}, "x": "b"}},
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.données.t[1]", Old: 2.0, New: 3.0},
+			},
+		},
+		{
+			options: []DifferOption{DifferPrefix("x")},
+			a:       1.0,
+			b:       2.0,
+			expected: []Change{
+				{Op: ChangeReplace, Path: "x", Old: 1.0, New: 2.0},
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewDiffer(tc.options...).Diff(tc.a, tc.b))
+		})
+	}
+}
+
+func TestDifferNormalize(t *testing.T) {
+	for i, tc := range []struct {
+		options   []DifferOption
+		a         interface{}
+		b         interface{}
+		expectedA interface{}
+		expectedB interface{}
+	}{
+		{
+			a:         []interface{}{"x", "y", "z"},
+			b:         []interface{}{"z", "x", "y"},
+			expectedA: []interface{}{"x", "y", "z"},
+			expectedB: []interface{}{"z", "x", "y"},
+		},
+		{
+			options:   []DifferOption{DifferDetectMoves()},
+			a:         []interface{}{"x", "y", "z"},
+			b:         []interface{}{"z", "x", "y"},
+			expectedA: []interface{}{"z", "x", "y"},
+			expectedB: []interface{}{"z", "x", "y"},
+		},
+		{
+			options:   []DifferOption{DifferDetectMoves()},
+			a:         map[string]interface{}{"a": []interface{}{"x", "y", "z"}, "b": 1.0},
+			b:         map[string]interface{}{"a": []interface{}{"y", "w", "x"}},
+			expectedA: map[string]interface{}{"a": []interface{}{"y", "z", "x"}, "b": 1.0},
+			expectedB: map[string]interface{}{"a": []interface{}{"y", "w", "x"}},
+		},
+		{
+			options:   []DifferOption{DifferNumericEquality(), DifferNullEqualsMissing()},
+			a:         map[string]interface{}{"a": json.Number("1"), "b": nil},
+			b:         map[string]interface{}{"a": json.Number("1.0")},
+			expectedA: map[string]interface{}{"a": json.Number("1")},
+			expectedB: map[string]interface{}{"a": json.Number("1")},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actualA, actualB := NewDiffer(tc.options...).Normalize(tc.a, tc.b)
+			assert.Equal(t, tc.expectedA, actualA)
+			assert.Equal(t, tc.expectedB, actualB)
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	changes := []Change{
+		{Op: ChangeAdd, Path: "root.a"},
+		{Op: ChangeMove, Path: "root.items[0]", From: "root.items[3]"},
+	}
+	actual := make([]string, 0, len(changes))
+	for _, change := range changes {
+		actual = append(actual, change.String())
+	}
+	assert.Equal(t, "add root.a\nmove root.items[3] -> root.items[0]", strings.Join(actual, "\n"))
+}
+
+func TestChangeMarshalJSON(t *testing.T) {
+	for i, tc := range []struct {
+		change   Change
+		expected string
+	}{
+		{
+			change:   Change{Op: ChangeAdd, Path: "root.a", New: false},
+			expected: `{"op":"add","path":"root.a","new":false}`,
+		},
+		{
+			change:   Change{Op: ChangeRemove, Path: "root.a"},
+			expected: `{"op":"remove","path":"root.a","old":null}`,
+		},
+		{
+			change:   Change{Op: ChangeReplace, Path: "root[0]", Old: json.Number("1"), New: map[string]interface{}{}},
+			expected: `{"op":"replace","path":"root[0]","old":1,"new":{}}`,
+		},
+		{
+			change:   Change{Op: ChangeMove, Path: "root[0]", From: "root[2]"},
+			expected: `{"op":"move","path":"root[0]","from":"root[2]"}`,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := json.Marshal(tc.change)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestLongestIncreasingSubsequence(t *testing.T) {
+	for i, tc := range []struct {
+		values   []int
+		expected []int
+	}{
+		{values: nil, expected: []int{}},
+		{values: []int{0}, expected: []int{0}},
+		{values: []int{2, 0, 1}, expected: []int{1, 2}},
+		{values: []int{3, 1, 2, 0, 4}, expected: []int{1, 2, 4}},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, longestIncreasingSubsequence(tc.values))
+		})
+	}
+}
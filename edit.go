@@ -0,0 +1,478 @@
+package flatjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GetPath returns the value at path in root. The identifier of path is
+// ignored.
+func GetPath(root interface{}, path string) (interface{}, error) {
+	identifier, properties, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	value := root
+	for i, property := range properties {
+		parent := FormatPath(identifier, properties[:i])
+		switch property := property.(type) {
+		case int:
+			array, ok := value.([]interface{})
+			switch {
+			case !ok:
+				return nil, fmt.Errorf("%s: %s, not an array", parent, typeName(value))
+			case property >= len(array):
+				return nil, fmt.Errorf("%s: not found", FormatPath(identifier, properties[:i+1]))
+			}
+			value = array[property]
+		case string:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: %s, not an object", parent, typeName(value))
+			}
+			if value, ok = object[property]; !ok {
+				return nil, fmt.Errorf("%s: not found", FormatPath(identifier, properties[:i+1]))
+			}
+		}
+	}
+	return value, nil
+}
+
+// SetPath sets the value at path in root to value and returns the new root.
+// Missing objects and arrays along path are created. Unlike MergeValues, SetPath
+// returns an error instead of replacing values of the wrong type along path
+// and arrays can only be extended by one element at a time. The identifier of
+// path is ignored.
+func SetPath(root interface{}, path string, value interface{}) (interface{}, error) {
+	identifier, properties, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return setPathHelper(identifier, properties, 0, root, true, value)
+}
+
+// DeletePath deletes the value at path in root and returns the new root.
+// Deleting an element of an array shifts the following elements down. The
+// identifier of path is ignored.
+func DeletePath(root interface{}, path string) (interface{}, error) {
+	identifier, properties, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, fmt.Errorf("%s: cannot delete root", path)
+	}
+	n := len(properties) - 1
+	parent, err := GetPath(root, FormatPath(identifier, properties[:n]))
+	if err != nil {
+		return nil, err
+	}
+	switch property := properties[n].(type) {
+	case int:
+		array, ok := parent.([]interface{})
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s: %s, not an array", FormatPath(identifier, properties[:n]), typeName(parent))
+		case property >= len(array):
+			return nil, fmt.Errorf("%s: not found", path)
+		}
+		array = append(array[:property], array[property+1:]...)
+		return SetPath(root, FormatPath(identifier, properties[:n]), array)
+	case string:
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: %s, not an object", FormatPath(identifier, properties[:n]), typeName(parent))
+		}
+		if _, ok := object[property]; !ok {
+			return nil, fmt.Errorf("%s: not found", path)
+		}
+		delete(object, property)
+	}
+	return root, nil
+}
+
+func setPathHelper(identifier string, properties []interface{}, i int, root interface{}, exists bool, value interface{}) (interface{}, error) {
+	if i == len(properties) {
+		return value, nil
+	}
+	path := FormatPath(identifier, properties[:i])
+	switch property := properties[i].(type) {
+	case int:
+		array, ok := root.([]interface{})
+		switch {
+		case !exists:
+			array = []interface{}{}
+		case !ok:
+			return nil, fmt.Errorf("%s: %s, not an array", path, typeName(root))
+		}
+		if property > len(array) {
+			return nil, fmt.Errorf("%s: index out of range", FormatPath(identifier, properties[:i+1]))
+		}
+		if property == len(array) {
+			element, err := setPathHelper(identifier, properties, i+1, nil, false, value)
+			if err != nil {
+				return nil, err
+			}
+			return append(array, element), nil
+		}
+		element, err := setPathHelper(identifier, properties, i+1, array[property], true, value)
+		if err != nil {
+			return nil, err
+		}
+		array[property] = element
+		return array, nil
+	case string:
+		object, ok := root.(map[string]interface{})
+		switch {
+		case !exists:
+			object = make(map[string]interface{})
+		case !ok:
+			return nil, fmt.Errorf("%s: %s, not an object", path, typeName(root))
+		}
+		child, childExists := object[property]
+		element, err := setPathHelper(identifier, properties, i+1, child, childExists, value)
+		if err != nil {
+			return nil, err
+		}
+		object[property] = element
+		return object, nil
+	default:
+		panic(fmt.Sprintf("unexpected property %v (%T)", property, property))
+	}
+}
+
+// typeName returns the JSON type name of value.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case string:
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+// A jsonItem is the span of a property or element of an object or array in a
+// JSON document. For elements, start is the start of the value.
+type jsonItem struct {
+	key        string
+	start      int
+	valueStart int
+	end        int
+}
+
+// SetJSONPath sets the value at path in data, a JSON document, and returns the
+// new document. Only the edited value is rewritten, so the order of properties
+// and the layout of the rest of data are preserved. New properties are
+// appended to their object, and new values are indented like their siblings.
+// SetJSONPath returns the same errors as SetPath.
+func SetJSONPath(data []byte, path string, value interface{}) ([]byte, error) {
+	root, err := unmarshalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := SetPath(root, path, value); err != nil {
+		return nil, err
+	}
+	identifier, properties, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	start := skipJSONSpace(data, 0)
+	end := skipJSONValue(data, start)
+	for i, property := range properties {
+		items := jsonItems(data, start, end)
+		k := findJSONItem(items, property)
+		if k == -1 {
+			// The value at properties[:i+1] does not exist, so add it with
+			// any missing containers below it.
+			newValue, err := setPathHelper(identifier, properties, i+1, nil, false, value)
+			if err != nil {
+				return nil, err
+			}
+			return insertJSONItem(data, start, end, items, property, newValue)
+		}
+		start, end = items[k].valueStart, items[k].end
+	}
+	return replaceJSONValue(data, start, end, value)
+}
+
+// DeleteJSONPath deletes the value at path in data, a JSON document, and
+// returns the new document. Only the deleted property or element and its
+// separator are removed, so the order of properties and the layout of the rest
+// of data are preserved. DeleteJSONPath returns the same errors as DeletePath.
+func DeleteJSONPath(data []byte, path string) ([]byte, error) {
+	root, err := unmarshalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DeletePath(root, path); err != nil {
+		return nil, err
+	}
+	_, properties, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	start := skipJSONSpace(data, 0)
+	end := skipJSONValue(data, start)
+	parentStart, parentEnd := start, end
+	var items []jsonItem
+	k := -1
+	for _, property := range properties {
+		parentStart, parentEnd = start, end
+		items = jsonItems(data, start, end)
+		k = findJSONItem(items, property)
+		start, end = items[k].valueStart, items[k].end
+	}
+	switch {
+	case len(items) == 1:
+		return spliceJSON(data, parentStart+1, parentEnd-1, nil), nil
+	case k == 0:
+		return spliceJSON(data, items[0].start, items[1].start, nil), nil
+	default:
+		return spliceJSON(data, items[k-1].end, items[k].end, nil), nil
+	}
+}
+
+// unmarshalJSON returns the value of data, a single JSON value.
+func unmarshalJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// replaceJSONValue returns data with the value between start and end replaced
+// by value.
+func replaceJSONValue(data []byte, start, end int, value interface{}) ([]byte, error) {
+	text, err := formatJSONValue(data, value, jsonLineIndent(data, start))
+	if err != nil {
+		return nil, err
+	}
+	return spliceJSON(data, start, end, text), nil
+}
+
+// insertJSONItem returns data with property set to value in the object or
+// array between start and end, whose items are items. The new item is
+// appended and separated from the previous item like the previous items.
+func insertJSONItem(data []byte, start, end int, items []jsonItem, property, value interface{}) ([]byte, error) {
+	var space, separator []byte
+	switch {
+	case len(items) > 1:
+		space = data[skipJSONSpace(data, items[len(items)-2].end)+1 : items[len(items)-1].start]
+	case len(items) == 1 && bytes.IndexByte(data[start+1:items[0].start], '\n') != -1:
+		space = data[start+1 : items[0].start]
+	case len(items) == 1:
+		if jsonIndent(data) != "" {
+			space = []byte(" ")
+		}
+	default:
+		if indent := jsonIndent(data); indent != "" {
+			space = []byte("\n" + jsonLineIndent(data, start) + indent)
+		}
+	}
+	if len(items) > 0 && data[start] == '{' {
+		last := items[len(items)-1]
+		keyEnd := skipJSONValue(data, last.start)
+		separator = data[keyEnd:last.valueStart]
+	} else if jsonIndent(data) != "" {
+		separator = []byte(": ")
+	} else {
+		separator = []byte(":")
+	}
+
+	lineIndent := string(space)
+	if i := strings.LastIndexByte(lineIndent, '\n'); i != -1 {
+		lineIndent = lineIndent[i+1:]
+	} else {
+		lineIndent = jsonLineIndent(data, start)
+	}
+	text, err := formatJSONValue(data, value, lineIndent)
+	if err != nil {
+		return nil, err
+	}
+	item := &bytes.Buffer{}
+	if len(items) > 0 {
+		item.WriteByte(',')
+	}
+	item.Write(space)
+	if key, ok := property.(string); ok {
+		keyText, err := formatJSONValue(data, key, "")
+		if err != nil {
+			return nil, err
+		}
+		item.Write(keyText)
+		item.Write(separator)
+	}
+	item.Write(text)
+
+	if len(items) > 0 {
+		last := items[len(items)-1].end
+		return spliceJSON(data, last, last, item.Bytes()), nil
+	}
+	if len(space) > 0 {
+		item.WriteString("\n" + jsonLineIndent(data, start))
+	}
+	return spliceJSON(data, start+1, end-1, item.Bytes()), nil
+}
+
+// formatJSONValue returns the JSON encoding of value, indented like data if
+// data is indented, with lines after the first prefixed with lineIndent.
+func formatJSONValue(data []byte, value interface{}, lineIndent string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	e := json.NewEncoder(buffer)
+	e.SetEscapeHTML(false)
+	if indent := jsonIndent(data); indent != "" {
+		e.SetIndent(lineIndent, indent)
+	}
+	if err := e.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// jsonIndent returns the indentation of the first indented line of data, or
+// the empty string if data is not indented.
+func jsonIndent(data []byte) string {
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\n' {
+			continue
+		}
+		j := i + 1
+		for j < len(data) && (data[j] == ' ' || data[j] == '\t') {
+			j++
+		}
+		if j > i+1 && j < len(data) && data[j] != '\n' && data[j] != '\r' {
+			return string(data[i+1 : j])
+		}
+	}
+	return ""
+}
+
+// jsonLineIndent returns the leading whitespace of the line containing the
+// offset i in data.
+func jsonLineIndent(data []byte, i int) string {
+	start := bytes.LastIndexByte(data[:i], '\n') + 1
+	end := start
+	for end < i && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// jsonItems returns the properties or elements of the object or array
+// between start and end in data, which must be valid JSON. It returns nil if
+// the value is not an object or array.
+func jsonItems(data []byte, start, end int) []jsonItem {
+	if data[start] != '{' && data[start] != '[' {
+		return nil
+	}
+	var items []jsonItem
+	i := skipJSONSpace(data, start+1)
+	for i < end-1 {
+		item := jsonItem{start: i, valueStart: i}
+		if data[start] == '{' {
+			keyEnd := skipJSONValue(data, i)
+			if err := json.Unmarshal(data[i:keyEnd], &item.key); err != nil {
+				return nil
+			}
+			item.valueStart = skipJSONSpace(data, skipJSONSpace(data, keyEnd)+1)
+		}
+		item.end = skipJSONValue(data, item.valueStart)
+		items = append(items, item)
+		i = skipJSONSpace(data, item.end)
+		if data[i] == ',' {
+			i = skipJSONSpace(data, i+1)
+		}
+	}
+	return items
+}
+
+// findJSONItem returns the index of the item in items with property, or -1
+// if there is no such item. Like encoding/json, the last of several properties
+// with the same name is used.
+func findJSONItem(items []jsonItem, property interface{}) int {
+	switch property := property.(type) {
+	case int:
+		if property < len(items) {
+			return property
+		}
+	case string:
+		for k := len(items) - 1; k >= 0; k-- {
+			if items[k].key == property {
+				return k
+			}
+		}
+	}
+	return -1
+}
+
+// skipJSONSpace returns the offset of the first non-whitespace byte in data
+// at or after i.
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// skipJSONValue returns the offset of the end of the value that starts at i
+// in data, which must be valid JSON.
+func skipJSONValue(data []byte, i int) int {
+	switch data[i] {
+	case '"':
+		for i++; data[i] != '"'; i++ {
+			if data[i] == '\\' {
+				i++
+			}
+		}
+		return i + 1
+	case '{', '[':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				i = skipJSONValue(data, i) - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return i
+	default:
+		for i < len(data) && strings.IndexByte(" \t\n\r,:]}", data[i]) == -1 {
+			i++
+		}
+		return i
+	}
+}
+
+// spliceJSON returns a copy of data with the bytes between start and end
+// replaced by text.
+func spliceJSON(data []byte, start, end int, text []byte) []byte {
+	result := make([]byte, 0, len(data)-(end-start)+len(text))
+	result = append(result, data[:start]...)
+	result = append(result, text...)
+	return append(result, data[end:]...)
+}
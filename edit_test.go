@@ -0,0 +1,287 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func newEditTestRoot() interface{} {
+	return map[string]interface{}{
+		"a": map[string]interface{}{"b": json.Number("1")},
+		"c": []interface{}{"x", "y"},
+		"d": nil,
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	for i, tc := range []struct {
+		path        string
+		expected    interface{}
+		expectedErr string
+	}{
+		{path: "root", expected: newEditTestRoot()},
+		{path: "root.a.b", expected: json.Number("1")},
+		{path: "root.c[1]", expected: "y"},
+		{path: "root.d", expected: nil},
+		{path: "root.e", expectedErr: "root.e: not found"},
+		{path: "root.c[2]", expectedErr: "root.c[2]: not found"},
+		{path: "root.a[0]", expectedErr: "root.a: object, not an array"},
+		{path: "root.a.b.c", expectedErr: "root.a.b: number, not an object"},
+		{path: "root.", expectedErr: "expected [identifier], found eof"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := GetPath(newEditTestRoot(), tc.path)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	for i, tc := range []struct {
+		path        string
+		value       interface{}
+		expected    interface{}
+		expectedErr string
+	}{
+		{
+			path:     "root",
+			value:    true,
+			expected: true,
+		},
+		{
+			path:  "root.a.b",
+			value: json.Number("2"),
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"b": json.Number("2")},
+				"c": []interface{}{"x", "y"},
+				"d": nil,
+			},
+		},
+		{
+			path:  "root.c[2]",
+			value: "z",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"b": json.Number("1")},
+				"c": []interface{}{"x", "y", "z"},
+				"d": nil,
+			},
+		},
+		{
+			path:  "root.e.f[0].g",
+			value: false,
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"b": json.Number("1")},
+				"c": []interface{}{"x", "y"},
+				"d": nil,
+				"e": map[string]interface{}{
+					"f": []interface{}{
+						map[string]interface{}{"g": false},
+					},
+				},
+			},
+		},
+		{path: "root.c[3]", value: "z", expectedErr: "root.c[3]: index out of range"},
+		{path: "root.a.b.c", value: true, expectedErr: "root.a.b: number, not an object"},
+		{path: "root.d.e", value: true, expectedErr: "root.d: null, not an object"},
+		{path: "root.c.length", value: true, expectedErr: "root.c: array, not an object"},
+		{path: "root.a[0]", value: true, expectedErr: "root.a: object, not an array"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := SetPath(newEditTestRoot(), tc.path, tc.value)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDeletePath(t *testing.T) {
+	for i, tc := range []struct {
+		path        string
+		expected    interface{}
+		expectedErr string
+	}{
+		{
+			path: "root.a.b",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{},
+				"c": []interface{}{"x", "y"},
+				"d": nil,
+			},
+		},
+		{
+			path: "root.c[0]",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"b": json.Number("1")},
+				"c": []interface{}{"y"},
+				"d": nil,
+			},
+		},
+		{
+			path: "root.d",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"b": json.Number("1")},
+				"c": []interface{}{"x", "y"},
+			},
+		},
+		{path: "root", expectedErr: "root: cannot delete root"},
+		{path: "root.e", expectedErr: "root.e: not found"},
+		{path: "root.c[2]", expectedErr: "root.c[2]: not found"},
+		{path: "root.a.b.c", expectedErr: "root.a.b: number, not an object"},
+		{path: "root.a[0]", expectedErr: "root.a: object, not an array"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := DeletePath(newEditTestRoot(), tc.path)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetJSONPath(t *testing.T) {
+	data := "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": []},\n  \"m\": [\n    \"x\"\n  ],\n  \"e\": {}\n}\n"
+	for i, tc := range []struct {
+		data        string
+		path        string
+		value       interface{}
+		expected    string
+		expectedErr string
+	}{
+		{
+			data:     data,
+			path:     "root.z",
+			value:    json.Number("2"),
+			expected: "{\n  \"z\": 2,\n  \"a\": {\"y\": true, \"b\": []},\n  \"m\": [\n    \"x\"\n  ],\n  \"e\": {}\n}\n",
+		},
+		{
+			data:     data,
+			path:     "root.a.y",
+			value:    "<&>",
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": \"<&>\", \"b\": []},\n  \"m\": [\n    \"x\"\n  ],\n  \"e\": {}\n}\n",
+		},
+		{
+			data:     data,
+			path:     "root.c",
+			value:    false,
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": []},\n  \"m\": [\n    \"x\"\n  ],\n  \"e\": {},\n  \"c\": false\n}\n",
+		},
+		{
+			data:     data,
+			path:     "root.a.c",
+			value:    nil,
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": [], \"c\": null},\n  \"m\": [\n    \"x\"\n  ],\n  \"e\": {}\n}\n",
+		},
+		{
+			data:     data,
+			path:     "root.m[1]",
+			value:    "y",
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": []},\n  \"m\": [\n    \"x\",\n    \"y\"\n  ],\n  \"e\": {}\n}\n",
+		},
+		{
+			data:     data,
+			path:     "root.e.f[0].g",
+			value:    json.Number("1"),
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": []},\n  \"m\": [\n    \"x\"\n  ],\n  \"e\": {\n    \"f\": [\n      {\n        \"g\": 1\n      }\n    ]\n  }\n}\n",
+		},
+		{
+			data:     `{"b":[1,2],"a":{}}`,
+			path:     "root.a.x",
+			value:    []interface{}{json.Number("3")},
+			expected: `{"b":[1,2],"a":{"x":[3]}}`,
+		},
+		{
+			data:     `{"b":[1,2],"a":{}}`,
+			path:     "root.b[2]",
+			value:    json.Number("3"),
+			expected: `{"b":[1,2,3],"a":{}}`,
+		},
+		{
+			data:     "{\n  \"a\": {\"y\": 1},\n  \"b\": [{}]\n}",
+			path:     "root.a.b",
+			value:    json.Number("2"),
+			expected: "{\n  \"a\": {\"y\": 1, \"b\": 2},\n  \"b\": [{}]\n}",
+		},
+		{
+			data:     "{\n  \"a\": {\"y\": 1},\n  \"b\": [{}]\n}",
+			path:     "root.b[1]",
+			value:    map[string]interface{}{"c": true},
+			expected: "{\n  \"a\": {\"y\": 1},\n  \"b\": [{}, {\n    \"c\": true\n  }]\n}",
+		},
+		{
+			data:     " 1 ",
+			path:     "root",
+			value:    "x",
+			expected: ` "x" `,
+		},
+		{data: data, path: "root.m[2]", value: "z", expectedErr: "root.m[2]: index out of range"},
+		{data: data, path: "root.z.a", value: "z", expectedErr: "root.z: number, not an object"},
+		{data: "{", path: "root", value: "z", expectedErr: "unexpected EOF"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := SetJSONPath([]byte(tc.data), tc.path, tc.value)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestDeleteJSONPath(t *testing.T) {
+	data := "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": [1, 2]},\n  \"m\": [\n    \"x\"\n  ]\n}\n"
+	for i, tc := range []struct {
+		path        string
+		expected    string
+		expectedErr string
+	}{
+		{
+			path:     "root.z",
+			expected: "{\n  \"a\": {\"y\": true, \"b\": [1, 2]},\n  \"m\": [\n    \"x\"\n  ]\n}\n",
+		},
+		{
+			path:     "root.a",
+			expected: "{\n  \"z\": 1,\n  \"m\": [\n    \"x\"\n  ]\n}\n",
+		},
+		{
+			path:     "root.m",
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": [1, 2]}\n}\n",
+		},
+		{
+			path:     "root.a.b[0]",
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": [2]},\n  \"m\": [\n    \"x\"\n  ]\n}\n",
+		},
+		{
+			path:     "root.m[0]",
+			expected: "{\n  \"z\": 1,\n  \"a\": {\"y\": true, \"b\": [1, 2]},\n  \"m\": []\n}\n",
+		},
+		{path: "root", expectedErr: "root: cannot delete root"},
+		{path: "root.q", expectedErr: "root.q: not found"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := DeleteJSONPath([]byte(data), tc.path)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, string(actual))
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package flatjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// embeddedJSONMarkerRegexp matches the comments that mark containers that
+// were flattened from strings of embedded JSON, like `/* json */` or
+// `/* json indent "  " unescaped + "\n" */`.
+var embeddedJSONMarkerRegexp = regexp.MustCompile(`^/\* json(?: indent ("[^"]*"))?( unescaped)?( \+ "\\n")? \*/$`)
+
+// An embeddedJSONEncoding is an encoding of embedded JSON that can be
+// reproduced exactly. Object keys are always sorted.
+type embeddedJSONEncoding struct {
+	indent     string
+	escapeHTML bool
+	suffix     string
+}
+
+// An embeddedJSON is the path of a container that was flattened from a string
+// of embedded JSON, and the encoding of the string.
+type embeddedJSON struct {
+	properties []interface{}
+	encoding   embeddedJSONEncoding
+}
+
+// parseEmbeddedJSON returns s's value and encoding if s is an object or array
+// that is encoded exactly as encoding/json encodes it, with keys sorted,
+// either compact or indented with a single indent string, with or without
+// HTML escaping, and optionally followed by a newline.
+func parseEmbeddedJSON(s string) (interface{}, embeddedJSONEncoding, bool) {
+	var encoding embeddedJSONEncoding
+	data := s
+	if trimmed, ok := strings.CutSuffix(s, "\n"); ok {
+		data, encoding.suffix = trimmed, "\n"
+	}
+	if !strings.HasPrefix(data, "{") && !strings.HasPrefix(data, "[") {
+		return nil, embeddedJSONEncoding{}, false
+	}
+	d := json.NewDecoder(strings.NewReader(data))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, embeddedJSONEncoding{}, false
+	}
+	if _, secondLine, ok := strings.Cut(data, "\n"); ok {
+		encoding.indent = secondLine[:len(secondLine)-len(strings.TrimLeft(secondLine, " \t"))]
+		if encoding.indent == "" {
+			return nil, embeddedJSONEncoding{}, false
+		}
+	}
+	for _, escapeHTML := range []bool{true, false} {
+		encoding.escapeHTML = escapeHTML
+		if encoded, err := encoding.encode(value); err == nil && encoded == s {
+			return value, encoding, true
+		}
+	}
+	return nil, embeddedJSONEncoding{}, false
+}
+
+// encode returns value encoded with e.
+func (e embeddedJSONEncoding) encode(value interface{}) (string, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(e.escapeHTML)
+	encoder.SetIndent("", e.indent)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n") + e.suffix, nil
+}
+
+// marker returns the comment that marks a container that was flattened from
+// a string of embedded JSON in e.
+func (e embeddedJSONEncoding) marker() string {
+	sb := &strings.Builder{}
+	sb.WriteString("/* json")
+	if e.indent != "" {
+		sb.WriteString(" indent " + strconv.Quote(e.indent))
+	}
+	if !e.escapeHTML {
+		sb.WriteString(" unescaped")
+	}
+	if e.suffix == "\n" {
+		sb.WriteString(` + "\n"`)
+	}
+	sb.WriteString(" */")
+	return sb.String()
+}
+
+// parseEmbeddedJSONMarker returns the encoding of embedded JSON given by
+// comment, if comment is a marker.
+func parseEmbeddedJSONMarker(comment string) (embeddedJSONEncoding, bool) {
+	match := embeddedJSONMarkerRegexp.FindStringSubmatch(comment)
+	if match == nil {
+		return embeddedJSONEncoding{}, false
+	}
+	encoding := embeddedJSONEncoding{
+		escapeHTML: match[2] == "",
+	}
+	if match[1] != "" {
+		indent, err := strconv.Unquote(match[1])
+		if err != nil || indent == "" || strings.Trim(indent, " \t") != "" {
+			return embeddedJSONEncoding{}, false
+		}
+		encoding.indent = indent
+	}
+	if match[3] != "" {
+		encoding.suffix = "\n"
+	}
+	return encoding, true
+}
+
+// newEmbeddedJSON returns the embedded JSON marked by assignment, if any.
+func newEmbeddedJSON(assignment *assignment) *embeddedJSON {
+	if assignment.op != assignmentSet {
+		return nil
+	}
+	switch assignment.value.(type) {
+	case []interface{}, map[string]interface{}:
+	default:
+		return nil
+	}
+	encoding, ok := parseEmbeddedJSONMarker(assignment.comment)
+	if !ok {
+		return nil
+	}
+	return &embeddedJSON{properties: assignment.properties, encoding: encoding}
+}
+
+// stringifyEmbeddedJSON replaces the containers in root at the paths of
+// embeddedJSONs with strings of their JSON encodings and returns the new root.
+// Paths that are no longer containers are left unchanged.
+func stringifyEmbeddedJSON(root interface{}, embeddedJSONs []*embeddedJSON) (interface{}, error) {
+	sort.SliceStable(embeddedJSONs, func(i, j int) bool {
+		return len(embeddedJSONs[i].properties) > len(embeddedJSONs[j].properties)
+	})
+	for _, embeddedJSON := range embeddedJSONs {
+		value := lookupValue(root, embeddedJSON.properties)
+		switch value.(type) {
+		case []interface{}, map[string]interface{}:
+		default:
+			continue
+		}
+		s, err := embeddedJSON.encoding.encode(value)
+		if err != nil {
+			return nil, err
+		}
+		root = recursiveMerge(root, embeddedJSON.properties, s)
+	}
+	return root, nil
+}
@@ -11,9 +11,12 @@ import (
 
 // A Encoder flattens JSON.
 type Encoder struct {
-	w      io.Writer
-	prefix string
-	suffix string
+	w              io.Writer
+	prefix         string
+	suffix         string
+	maxDepth       int
+	omitContainers bool
+	utf8           bool
 }
 
 // A EncoderOption modifies a Encoder.
@@ -32,33 +35,59 @@ func NewEncoder(w io.Writer, options ...EncoderOption) *Encoder {
 	return e
 }
 
+// encodeEscaping returns how Encode escapes strings.
+func (e *Encoder) encodeEscaping() escaping {
+	if e.utf8 {
+		return escapeMinimal
+	}
+	return escapeJSON
+}
+
+// transcodeEscaping returns how Transcode escapes strings.
+func (e *Encoder) transcodeEscaping() escaping {
+	if e.utf8 {
+		return escapeMinimal
+	}
+	return escapeASCII
+}
+
 // Transcode reads JSON tokens from d and writes their flatjson
 // representation.
 func (e *Encoder) Transcode(d *json.Decoder) error {
-	return e.writeDecoderHelper(d, e.prefix)
+	return e.writeDecoderHelper(d, e.prefix, 0)
 }
 
-func (e *Encoder) writeDecoderHelper(d *json.Decoder, prefix string) error {
+func (e *Encoder) writeDecoderHelper(d *json.Decoder, prefix string, depth int) error {
 	token, err := d.Token()
 	if err != nil {
 		return err
 	}
+	if delim, ok := token.(json.Delim); ok && e.maxDepth > 0 && depth >= e.maxDepth {
+		sb := &strings.Builder{}
+		if err := writeInlineTokens(sb, d, delim, e.transcodeEscaping()); err != nil {
+			return err
+		}
+		_, err := e.w.Write([]byte(prefix + " = " + sb.String() + e.suffix))
+		return err
+	}
 	switch token := token.(type) {
 	case bool:
 		_, err := e.w.Write([]byte(prefix + " = " + strconv.FormatBool(token) + e.suffix))
 		return err
 	case float64:
-		_, err := e.w.Write([]byte(prefix + " = " + strconv.FormatFloat(token, 'e', -1, 64) + e.suffix))
+		_, err := e.w.Write([]byte(prefix + " = " + formatFloatToken(token) + e.suffix))
 		return err
 	case json.Delim:
 		switch token {
 		case '[':
 			index := 0
-			if _, err := e.w.Write([]byte(prefix + " = []" + e.suffix)); err != nil {
-				return err
+			if !e.omitContainers || !d.More() {
+				if _, err := e.w.Write([]byte(prefix + " = []" + e.suffix)); err != nil {
+					return err
+				}
 			}
 			for d.More() {
-				err := e.writeDecoderHelper(d, prefix+"["+strconv.Itoa(index)+"]")
+				err := e.writeDecoderHelper(d, prefix+"["+strconv.Itoa(index)+"]", depth+1)
 				if err != nil {
 					return err
 				}
@@ -67,8 +96,10 @@ func (e *Encoder) writeDecoderHelper(d *json.Decoder, prefix string) error {
 			_, err := d.Token() // ']'
 			return err
 		case '{':
-			if _, err := e.w.Write([]byte(prefix + " = {}" + e.suffix)); err != nil {
-				return err
+			if !e.omitContainers || !d.More() {
+				if _, err := e.w.Write([]byte(prefix + " = {}" + e.suffix)); err != nil {
+					return err
+				}
 			}
 			for d.More() {
 				propertyToken, err := d.Token()
@@ -79,7 +110,7 @@ func (e *Encoder) writeDecoderHelper(d *json.Decoder, prefix string) error {
 				if !ok {
 					return fmt.Errorf("expected a string, got %v", propertyToken)
 				}
-				if err := e.writeDecoderHelper(d, propertyAccessor(prefix, property)); err != nil {
+				if err := e.writeDecoderHelper(d, propertyAccessor(prefix, property, e.utf8), depth+1); err != nil {
 					return err
 				}
 			}
@@ -92,7 +123,7 @@ func (e *Encoder) writeDecoderHelper(d *json.Decoder, prefix string) error {
 		_, err := e.w.Write([]byte(prefix + " = " + token.String() + e.suffix))
 		return err
 	case string:
-		_, err := e.w.Write([]byte(prefix + " = " + strconv.QuoteToASCII(token) + e.suffix))
+		_, err := e.w.Write([]byte(prefix + " = " + quoteString(token, e.transcodeEscaping()) + e.suffix))
 		return err
 	case nil:
 		_, err := e.w.Write([]byte(prefix + " = null" + e.suffix))
@@ -102,26 +133,81 @@ func (e *Encoder) writeDecoderHelper(d *json.Decoder, prefix string) error {
 	}
 }
 
+// formatFloatToken returns the representation of token, a number read from a
+// json.Decoder that does not use json.Number.
+func formatFloatToken(token float64) string {
+	return strconv.FormatFloat(token, 'e', -1, 64)
+}
+
+// writeInlineTokens writes the JSON tokens read from d up to the delimiter
+// that closes delim to sb as compact JSON, with strings escaped according to
+// esc.
+func writeInlineTokens(sb *strings.Builder, d *json.Decoder, delim json.Delim, esc escaping) error {
+	sb.WriteRune(rune(delim))
+	for i := 0; d.More(); i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if delim == '{' {
+			property, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if err := writeValue(sb, property, esc); err != nil {
+				return err
+			}
+			sb.WriteByte(':')
+		}
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			if err := writeInlineTokens(sb, d, delim, esc); err != nil {
+				return err
+			}
+			continue
+		}
+		if token, ok := token.(float64); ok {
+			sb.WriteString(formatFloatToken(token))
+			continue
+		}
+		if err := writeValue(sb, token, esc); err != nil {
+			return err
+		}
+	}
+	token, err := d.Token()
+	if err != nil {
+		return err
+	}
+	sb.WriteString(token.(json.Delim).String())
+	return nil
+}
+
 // Encode encodes value.
 func (e *Encoder) Encode(value interface{}) error {
-	return e.encodeHelper(e.prefix, value)
+	return e.encodeHelper(e.prefix, 0, value)
 }
 
-func (e *Encoder) encodeArray(prefix string, array []interface{}) error {
-	if _, err := e.w.Write([]byte(prefix + " = []" + e.suffix)); err != nil {
-		return err
+func (e *Encoder) encodeArray(prefix string, depth int, array []interface{}) error {
+	if !e.omitContainers || len(array) == 0 {
+		if _, err := e.w.Write([]byte(prefix + " = []" + e.suffix)); err != nil {
+			return err
+		}
 	}
 	for i, value := range array {
-		if err := e.encodeHelper(prefix+"["+strconv.Itoa(i)+"]", value); err != nil {
+		if err := e.encodeHelper(prefix+"["+strconv.Itoa(i)+"]", depth+1, value); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *Encoder) encodeObject(prefix string, object map[string]interface{}) error {
-	if _, err := e.w.Write([]byte(prefix + " = {}" + e.suffix)); err != nil {
-		return err
+func (e *Encoder) encodeObject(prefix string, depth int, object map[string]interface{}) error {
+	if !e.omitContainers || len(object) == 0 {
+		if _, err := e.w.Write([]byte(prefix + " = {}" + e.suffix)); err != nil {
+			return err
+		}
 	}
 	properties := make([]string, 0, len(object))
 	for property := range object {
@@ -129,27 +215,31 @@ func (e *Encoder) encodeObject(prefix string, object map[string]interface{}) err
 	}
 	sort.Strings(properties)
 	for _, property := range properties {
-		if err := e.encodeHelper(propertyAccessor(prefix, property), object[property]); err != nil {
+		if err := e.encodeHelper(propertyAccessor(prefix, property, e.utf8), depth+1, object[property]); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *Encoder) encodeHelper(prefix string, value interface{}) error {
+func (e *Encoder) encodeHelper(prefix string, depth int, value interface{}) error {
+	inline := e.maxDepth > 0 && depth >= e.maxDepth
 	switch value := value.(type) {
 	case []interface{}:
-		return e.encodeArray(prefix, value)
+		if !inline {
+			return e.encodeArray(prefix, depth, value)
+		}
 	case map[string]interface{}:
-		return e.encodeObject(prefix, value)
-	default:
-		data, err := json.Marshal(value)
-		if err != nil {
-			return err
+		if !inline {
+			return e.encodeObject(prefix, depth, value)
 		}
-		_, err = e.w.Write([]byte(prefix + " = " + string(data) + e.suffix))
+	}
+	data, err := marshalValue(value, e.encodeEscaping())
+	if err != nil {
 		return err
 	}
+	_, err = e.w.Write([]byte(prefix + " = " + data + e.suffix))
+	return err
 }
 
 // Marshal returns the flatjson encoding of v.
@@ -161,6 +251,25 @@ func Marshal(v interface{}, options ...EncoderOption) ([]byte, error) {
 	return []byte(sb.String()), nil
 }
 
+// EncoderMaxDepth sets the maximum depth of paths. Objects and arrays at depth,
+// which is the number of property accesses in their path, are written as JSON
+// on a single line. If depth is zero, which is the default, then there is no
+// maximum depth.
+func EncoderMaxDepth(depth int) EncoderOption {
+	return func(e *Encoder) {
+		e.maxDepth = depth
+	}
+}
+
+// EncoderOmitContainers omits the declarations of non-empty objects and
+// arrays, like `root.a = {};`, so only leaf values are written. Declarations of
+// empty objects and arrays are still written.
+func EncoderOmitContainers() EncoderOption {
+	return func(e *Encoder) {
+		e.omitContainers = true
+	}
+}
+
 // EncoderPrefix sets the prefix.
 func EncoderPrefix(prefix string) EncoderOption {
 	return func(e *Encoder) {
@@ -168,6 +277,16 @@ func EncoderPrefix(prefix string) EncoderOption {
 	}
 }
 
+// EncoderUTF8 writes strings with only the escapes that JSON and JavaScript
+// require, and property names that are non-ASCII identifiers with dot
+// notation, like WithUTF8. Without it, Encode escapes strings like
+// encoding/json and Transcode escapes all non-ASCII characters.
+func EncoderUTF8() EncoderOption {
+	return func(e *Encoder) {
+		e.utf8 = true
+	}
+}
+
 // EncoderSuffix sets the suffix.
 func EncoderSuffix(suffix string) EncoderOption {
 	return func(e *Encoder) {
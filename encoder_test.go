@@ -13,6 +13,7 @@ import (
 func TestWriteValue(t *testing.T) {
 	for i, tc := range []struct {
 		json     string
+		options  []EncoderOption
 		expected string
 	}{
 		{
@@ -67,12 +68,47 @@ func TestWriteValue(t *testing.T) {
 			json:     `{"true":false}`,
 			expected: "root = {};\nroot[\"true\"] = false;\n",
 		},
+		{
+			json:     `{"a":{"b":[1,{"c":"d"}]},"e":[]}`,
+			options:  []EncoderOption{EncoderMaxDepth(1)},
+			expected: "root = {};\nroot.a = {\"b\":[1,{\"c\":\"d\"}]};\nroot.e = [];\n",
+		},
+		{
+			json:     `{"a":{"b":[1,{"c":"d"}]}}`,
+			options:  []EncoderOption{EncoderMaxDepth(2)},
+			expected: "root = {};\nroot.a = {};\nroot.a.b = [1,{\"c\":\"d\"}];\n",
+		},
+		{
+			json:     `{"a":{"b":[1,{"c":"d"}]},"e":[]}`,
+			options:  []EncoderOption{EncoderOmitContainers()},
+			expected: "root.a.b[0] = 1;\nroot.a.b[1].c = \"d\";\nroot.e = [];\n",
+		},
+		{
+			json:     `{"données":"é😀","x":["é"]}`,
+			options:  []EncoderOption{EncoderMaxDepth(1)},
+			expected: "root = {};\nroot[\"données\"] = \"é😀\";\nroot.x = [\"é\"];\n",
+		},
+		{
+			json:     `{"<":"é","a":["<&>"]}`,
+			options:  []EncoderOption{EncoderMaxDepth(1)},
+			expected: "root = {};\nroot[\"<\"] = \"é\";\nroot.a = [\"\\u003c\\u0026\\u003e\"];\n",
+		},
+		{
+			json:     `{"<":"é","a":["<&>"]}`,
+			options:  []EncoderOption{EncoderMaxDepth(1), EncoderUTF8()},
+			expected: "root = {};\nroot[\"<\"] = \"é\";\nroot.a = [\"<&>\"];\n",
+		},
+		{
+			json:     `{"données":"é😀","x":["é"]}`,
+			options:  []EncoderOption{EncoderMaxDepth(1), EncoderUTF8()},
+			expected: "root = {};\nroot.données = \"é😀\";\nroot.x = [\"é\"];\n",
+		},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			sb := &strings.Builder{}
 			var value interface{}
 			assert.NoError(t, json.Unmarshal([]byte(tc.json), &value))
-			assert.NoError(t, NewEncoder(sb).Encode(value))
+			assert.NoError(t, NewEncoder(sb, tc.options...).Encode(value))
 			assert.Equal(t, tc.expected, sb.String())
 		})
 	}
@@ -83,6 +119,7 @@ func TestFlattenDecoder(t *testing.T) {
 		s         string
 		prefix    string
 		useNumber bool
+		options   []EncoderOption
 		expected  string
 	}{
 		{
@@ -155,6 +192,57 @@ func TestFlattenDecoder(t *testing.T) {
 			useNumber: true,
 			expected:  "root = {};\nroot[\"quoted.prop\"] = 0;\n",
 		},
+		{
+			s:         "{\"a\":{\"b\": [1.50, {\"c\": \"<d>\"}, []]}, \"e\": {}}",
+			prefix:    "root",
+			useNumber: true,
+			options:   []EncoderOption{EncoderMaxDepth(1)},
+			expected:  "root = {};\nroot.a = {\"b\":[1.50,{\"c\":\"<d>\"},[]]};\nroot.e = {};\n",
+		},
+		{
+			s:        "{\"a\":1.5,\"b\":[1.5,{\"c\":100}]}",
+			prefix:   "root",
+			options:  []EncoderOption{EncoderMaxDepth(1)},
+			expected: "root = {};\nroot.a = 1.5e+00;\nroot.b = [1.5e+00,{\"c\":1e+02}];\n",
+		},
+		{
+			s:         "[[1,[2]],3]",
+			prefix:    "root",
+			useNumber: true,
+			options:   []EncoderOption{EncoderMaxDepth(2)},
+			expected:  "root = [];\nroot[0] = [];\nroot[0][0] = 1;\nroot[0][1] = [2];\nroot[1] = 3;\n",
+		},
+		{
+			s:         "{\"a\":[{\"b\":1},[]],\"c\":{}}",
+			prefix:    "root",
+			useNumber: true,
+			options:   []EncoderOption{EncoderOmitContainers()},
+			expected:  "root.a[0].b = 1;\nroot.a[1] = [];\nroot.c = {};\n",
+		},
+		{
+			s:        "{\"données\":\"é\\u0001\",\"x\":{\"é\":\"é\"}}",
+			prefix:   "root",
+			options:  []EncoderOption{EncoderMaxDepth(1)},
+			expected: "root = {};\nroot[\"données\"] = \"\\u00e9\\u0001\";\nroot.x = {\"\\u00e9\":\"\\u00e9\"};\n",
+		},
+		{
+			s:        "{\"<\":\"é\",\"a\":[\"<&>\"]}",
+			prefix:   "root",
+			options:  []EncoderOption{EncoderMaxDepth(1)},
+			expected: "root = {};\nroot[\"<\"] = \"\\u00e9\";\nroot.a = [\"<&>\"];\n",
+		},
+		{
+			s:        "{\"<\":\"é\",\"a\":[\"<&>\"]}",
+			prefix:   "root",
+			options:  []EncoderOption{EncoderMaxDepth(1), EncoderUTF8()},
+			expected: "root = {};\nroot[\"<\"] = \"é\";\nroot.a = [\"<&>\"];\n",
+		},
+		{
+			s:        "{\"données\":\"é\\u0001\",\"x\":{\"é\":\"é\"}}",
+			prefix:   "root",
+			options:  []EncoderOption{EncoderMaxDepth(1), EncoderUTF8()},
+			expected: "root = {};\nroot.données = \"é\\u0001\";\nroot.x = {\"é\":\"é\"};\n",
+		},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			d := json.NewDecoder(bytes.NewBufferString(tc.s))
@@ -162,7 +250,7 @@ func TestFlattenDecoder(t *testing.T) {
 				d.UseNumber()
 			}
 			sb := &strings.Builder{}
-			e := NewEncoder(sb, EncoderPrefix(tc.prefix))
+			e := NewEncoder(sb, append([]EncoderOption{EncoderPrefix(tc.prefix)}, tc.options...)...)
 			assert.NoError(t, e.Transcode(d))
 			assert.Equal(t, tc.expected, sb.String())
 		})
@@ -5,39 +5,79 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 )
 
-var (
-	unquotedPropertyNameRegexp = regexp.MustCompile(`^[A-Za-z_][0-9A-Za-z_]*$`)
-
-	keywords = map[string]bool{
-		"false": true,
-		"null":  true,
-		"true":  true,
-	}
-)
+var keywords = map[string]bool{
+	"false": true,
+	"null":  true,
+	"true":  true,
+}
 
 // A Flattener converts JSON into flat JSON.
 type Flattener struct {
-	w      io.Writer
-	prefix string
-	suffix string
+	w                  io.Writer
+	prefix             string
+	suffix             string
+	unorderedArrays    []string
+	maxDepth           int
+	inlineScalarArrays bool
+	omitContainers     bool
+	annotations        AnnotationStyle
+	templateLiterals   bool
+	embeddedJSON       bool
+	utf8               bool
 }
 
 // A FlattenerOption sets an option on a Flattener.
 type FlattenerOption func(*Flattener)
 
-func propertyAccessor(path, name string) string {
-	if unquotedPropertyNameRegexp.MatchString(name) && !keywords[name] {
+// propertyAccessor returns the path of the property name of path. If name is
+// not an ECMAScript identifier name, is a keyword, or, unless utf8Output is
+// true, contains non-ASCII characters, then it is quoted with quoteString.
+// Quoted names are escaped with escapeMinimal, like %q.
+func propertyAccessor(path, name string, utf8Output bool) string {
+	if isIdentifierName(name) && !keywords[name] && (utf8Output || isASCII(name)) {
 		if path == "" {
 			return name
 		}
 		return path + "." + name
 	}
-	return fmt.Sprintf("%s[%q]", path, name)
+	return path + "[" + quoteString(name, escapeMinimal) + "]"
+}
+
+// FormatPath returns the path of properties of identifier, in the same syntax
+// that is written by a Flattener. It is the inverse of ParsePath.
+func FormatPath(identifier string, properties []interface{}) string {
+	return formatPath(identifier, properties, false)
+}
+
+// formatPath returns the path of properties of identifier. If utf8Output is
+// true then non-ASCII identifiers are written with dot notation.
+func formatPath(identifier string, properties []interface{}, utf8Output bool) string {
+	path := identifier
+	for _, property := range properties {
+		switch property := property.(type) {
+		case int:
+			path += "[" + strconv.Itoa(property) + "]"
+		case string:
+			path = propertyAccessor(path, property, utf8Output)
+		}
+	}
+	return path
+}
+
+// formatPathLike returns the path of properties of identifier in the same
+// notation as like, which starts with the path of properties or of one of their
+// descendants. Non-ASCII identifiers are written with dot notation only if like
+// writes them so.
+func formatPathLike(like, identifier string, properties []interface{}) string {
+	if path := formatPath(identifier, properties, true); strings.HasPrefix(like, path) {
+		return path
+	}
+	return FormatPath(identifier, properties)
 }
 
 // NewFlattener returns a new Flattener that writes to w.
@@ -53,20 +93,60 @@ func NewFlattener(w io.Writer, options ...FlattenerOption) *Flattener {
 	return f
 }
 
-func (f *Flattener) writeArrayValues(path string, array []interface{}) error {
-	if _, err := fmt.Fprintf(f.w, "%s = []%s", path, f.suffix); err != nil {
+// escaping returns how f escapes strings.
+func (f *Flattener) escaping() escaping {
+	if f.utf8 {
+		return escapeMinimal
+	}
+	return escapeJSON
+}
+
+// writeDeclaration writes the declaration of the container at path with size
+// elements, including any annotation. If marker is not empty then it is
+// written as the comment of the declaration, which is never omitted.
+func (f *Flattener) writeDeclaration(path string, array bool, size int, marker string) error {
+	if f.omitContainers && size > 0 && marker == "" {
+		return nil
+	}
+	literal := "{}"
+	if array {
+		literal = "[]"
+	}
+	comment := marker
+	if comment == "" && f.annotations == AnnotationsComment {
+		comment = sizeComment(array, size)
+	}
+	suffix := f.suffix
+	if comment != "" {
+		if trimmed, ok := strings.CutSuffix(suffix, "\n"); ok {
+			suffix = trimmed + " " + comment + "\n"
+		} else {
+			suffix += " " + comment
+		}
+	}
+	_, err := fmt.Fprintf(f.w, "%s = %s%s", path, literal, suffix)
+	return err
+}
+
+func (f *Flattener) writeArrayValues(path string, depth int, array []interface{}, marker string) error {
+	if err := f.writeDeclaration(path, true, len(array), marker); err != nil {
 		return err
 	}
 	for i, value := range array {
-		if err := f.writeValuesHelper(path+"["+strconv.Itoa(i)+"]", value); err != nil {
+		if err := f.writeValuesHelper(path+"["+strconv.Itoa(i)+"]", depth+1, value); err != nil {
+			return err
+		}
+	}
+	if f.annotations == AnnotationsLength {
+		if _, err := fmt.Fprintf(f.w, "%s.length = %d%s", path, len(array), f.suffix); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (f *Flattener) writeObjectValues(path string, object map[string]interface{}) error {
-	if _, err := fmt.Fprintf(f.w, "%s = {}%s", path, f.suffix); err != nil {
+func (f *Flattener) writeObjectValues(path string, depth int, object map[string]interface{}, marker string) error {
+	if err := f.writeDeclaration(path, false, len(object), marker); err != nil {
 		return err
 	}
 	properties := make([]string, 0, len(object))
@@ -75,27 +155,54 @@ func (f *Flattener) writeObjectValues(path string, object map[string]interface{}
 	}
 	sort.Strings(properties)
 	for _, property := range properties {
-		if err := f.writeValuesHelper(propertyAccessor(path, property), object[property]); err != nil {
+		if err := f.writeValuesHelper(propertyAccessor(path, property, f.utf8), depth+1, object[property]); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (f *Flattener) writeValuesHelper(path string, value interface{}) error {
+func (f *Flattener) writeValuesHelper(path string, depth int, value interface{}) error {
+	inline := f.maxDepth > 0 && depth >= f.maxDepth
 	switch value := value.(type) {
 	case []interface{}:
-		return f.writeArrayValues(path, value)
+		if !inline && !(f.inlineScalarArrays && isScalarArray(value)) {
+			return f.writeArrayValues(path, depth, value, "")
+		}
 	case map[string]interface{}:
-		return f.writeObjectValues(path, value)
-	default:
-		data, err := json.Marshal(value)
-		if err != nil {
+		if !inline {
+			return f.writeObjectValues(path, depth, value, "")
+		}
+	case string:
+		if f.embeddedJSON && !inline {
+			if embeddedValue, encoding, ok := parseEmbeddedJSON(value); ok {
+				if array, ok := embeddedValue.([]interface{}); ok {
+					return f.writeArrayValues(path, depth, array, encoding.marker())
+				}
+				return f.writeObjectValues(path, depth, embeddedValue.(map[string]interface{}), encoding.marker())
+			}
+		}
+		if f.templateLiterals && strings.Contains(value, "\n") {
+			_, err := f.w.Write([]byte(path + " = " + quoteTemplateLiteral(value, f.escaping()) + f.suffix))
 			return err
 		}
-		_, err = f.w.Write([]byte(path + " = " + string(data) + f.suffix))
+	}
+	data, err := marshalValue(value, f.escaping())
+	if err != nil {
 		return err
 	}
+	_, err = f.w.Write([]byte(path + " = " + data + f.suffix))
+	return err
+}
+
+// isScalarArray returns whether array is non-empty and contains only scalars.
+func isScalarArray(array []interface{}) bool {
+	for _, element := range array {
+		if !isScalar(element) {
+			return false
+		}
+	}
+	return len(array) > 0
 }
 
 // WriteValues decodes JSON from data and writes it.
@@ -106,7 +213,15 @@ func (f *Flattener) WriteValues(data []byte) error {
 	if err := d.Decode(&value); err != nil {
 		return err
 	}
-	return f.writeValuesHelper(f.prefix, value)
+	return f.WriteValue(value)
+}
+
+// WriteValue writes value.
+func (f *Flattener) WriteValue(value interface{}) error {
+	if len(f.unorderedArrays) > 0 {
+		value = sortUnorderedArrays(f.prefix, value, f.unorderedArrays, f.utf8)
+	}
+	return f.writeValuesHelper(f.prefix, 0, value)
 }
 
 // WithPrefix sets the prefix on a Flattener.
@@ -122,3 +237,134 @@ func WithSuffix(suffix string) FlattenerOption {
 		f.suffix = suffix
 	}
 }
+
+// WithMaxDepth sets the maximum depth of paths written by a Flattener. Objects
+// and arrays at depth, which is the number of property accesses in their path,
+// are written as JSON on a single line. If depth is zero, which is the default,
+// then there is no maximum depth.
+func WithMaxDepth(depth int) FlattenerOption {
+	return func(f *Flattener) {
+		f.maxDepth = depth
+	}
+}
+
+// WithInlineScalarArrays writes arrays that only contain scalars as JSON on a
+// single line.
+func WithInlineScalarArrays() FlattenerOption {
+	return func(f *Flattener) {
+		f.inlineScalarArrays = true
+	}
+}
+
+// WithOmitContainers omits the declarations of non-empty objects and arrays,
+// like `root.a = {};`, so only leaf values are written. Declarations of empty
+// objects and arrays are still written, and a Deepener infers the other objects
+// and arrays from the paths of their values.
+func WithOmitContainers() FlattenerOption {
+	return func(f *Flattener) {
+		f.omitContainers = true
+	}
+}
+
+// WithAnnotations sets the style of annotations of the sizes of containers.
+// AnnotationsLength writes the lengths of arrays, like `root.items.length = 3;`,
+// after their elements. AnnotationsComment writes the sizes of arrays and
+// objects in comments, like `/* 3 items */`, after their declarations.
+func WithAnnotations(style AnnotationStyle) FlattenerOption {
+	return func(f *Flattener) {
+		f.annotations = style
+	}
+}
+
+// WithEmbeddedJSON flattens strings that contain objects or arrays as if they
+// were objects or arrays, and marks their declarations with a comment like
+// `/* json */` that records their encoding, so that they are converted back
+// into identical strings when deepened. Only strings that encoding/json would
+// produce from their values are flattened: keys must be sorted and the JSON
+// must be compact or indented with a single indent string, with or without
+// HTML escaping, optionally followed by a newline.
+func WithEmbeddedJSON() FlattenerOption {
+	return func(f *Flattener) {
+		f.embeddedJSON = true
+	}
+}
+
+// WithTemplateLiterals writes strings that contain newlines as JavaScript
+// template literals, which are delimited by backticks and span several lines,
+// so that diffs of multiline strings show the lines that changed.
+func WithTemplateLiterals() FlattenerOption {
+	return func(f *Flattener) {
+		f.templateLiterals = true
+	}
+}
+
+// WithUTF8 writes strings with only the escapes that JSON and JavaScript
+// require, so <, >, and & are not escaped, and property names that are
+// non-ASCII identifiers with dot notation, like root.données. Without it,
+// strings are escaped like encoding/json.
+func WithUTF8() FlattenerOption {
+	return func(f *Flattener) {
+		f.utf8 = true
+	}
+}
+
+// WithUnorderedArrays sets the patterns of paths of arrays whose elements are
+// sorted by their flattened representation before they are written.
+func WithUnorderedArrays(patterns ...string) FlattenerOption {
+	return func(f *Flattener) {
+		f.unorderedArrays = patterns
+	}
+}
+
+// sortUnorderedArrays returns a copy of value in which the elements of arrays
+// whose paths match any of patterns are sorted by their flattened
+// representation. If utf8Output is true then paths are written as with
+// WithUTF8.
+func sortUnorderedArrays(path string, value interface{}, patterns []string, utf8Output bool) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		array := make([]interface{}, 0, len(value))
+		for i, element := range value {
+			array = append(array, sortUnorderedArrays(path+"["+strconv.Itoa(i)+"]", element, patterns, utf8Output))
+		}
+		if !matchAnyPattern(patterns, path) {
+			return array
+		}
+		keys := make([]string, 0, len(array))
+		for _, element := range array {
+			sb := &strings.Builder{}
+			if err := NewFlattener(sb, WithPrefix(""), WithSuffix("\n")).WriteValue(element); err != nil {
+				keys = append(keys, canonicalJSON(element))
+			} else {
+				keys = append(keys, sb.String())
+			}
+		}
+		sort.Sort(&sortedElements{
+			elements: array,
+			keys:     keys,
+		})
+		return array
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for property, propertyValue := range value {
+			object[property] = sortUnorderedArrays(propertyAccessor(path, property, utf8Output), propertyValue, patterns, utf8Output)
+		}
+		return object
+	default:
+		return value
+	}
+}
+
+// sortedElements sorts array elements by key.
+type sortedElements struct {
+	elements []interface{}
+	keys     []string
+}
+
+func (e *sortedElements) Len() int           { return len(e.elements) }
+func (e *sortedElements) Less(i, j int) bool { return e.keys[i] < e.keys[j] }
+
+func (e *sortedElements) Swap(i, j int) {
+	e.elements[i], e.elements[j] = e.elements[j], e.elements[i]
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+}
@@ -73,3 +73,320 @@ func TestWriteValues(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteValuesUnorderedArrays(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		patterns []string
+		expected string
+	}{
+		{
+			json:     `["b","a"]`,
+			expected: "root = [];\nroot[0] = \"b\";\nroot[1] = \"a\";\n",
+		},
+		{
+			json:     `["b","a"]`,
+			patterns: []string{"root"},
+			expected: "root = [];\nroot[0] = \"a\";\nroot[1] = \"b\";\n",
+		},
+		{
+			json:     `{"a":["y","x"],"b":["y","x"]}`,
+			patterns: []string{"root.b"},
+			expected: "root = {};\nroot.a = [];\nroot.a[0] = \"y\";\nroot.a[1] = \"x\";\nroot.b = [];\nroot.b[0] = \"x\";\nroot.b[1] = \"y\";\n",
+		},
+		{
+			json:     `[{"t":["y","x"]},{"t":["x","w"]}]`,
+			patterns: []string{"**"},
+			expected: "root = [];\nroot[0] = {};\nroot[0].t = [];\nroot[0].t[0] = \"w\";\nroot[0].t[1] = \"x\";\nroot[1] = {};\nroot[1].t = [];\nroot[1].t[0] = \"x\";\nroot[1].t[1] = \"y\";\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, WithUnorderedArrays(tc.patterns...)).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+		})
+	}
+}
+
+func TestWriteValuesInline(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		options  []FlattenerOption
+		expected string
+	}{
+		{
+			json:     `{"a":{"b":[1,{"c":2}]},"d":3}`,
+			options:  []FlattenerOption{WithMaxDepth(1)},
+			expected: "root = {};\nroot.a = {\"b\":[1,{\"c\":2}]};\nroot.d = 3;\n",
+		},
+		{
+			json:     `{"a":{"b":[1,{"c":2}]},"d":3}`,
+			options:  []FlattenerOption{WithMaxDepth(2)},
+			expected: "root = {};\nroot.a = {};\nroot.a.b = [1,{\"c\":2}];\nroot.d = 3;\n",
+		},
+		{
+			json:     `{"a":["x","y"],"b":[],"c":[1,[2]]}`,
+			options:  []FlattenerOption{WithInlineScalarArrays()},
+			expected: "root = {};\nroot.a = [\"x\",\"y\"];\nroot.b = [];\nroot.c = [];\nroot.c[0] = 1;\nroot.c[1] = [2];\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, tc.options...).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+			expected, err := UnmarshalInput([]byte(tc.json), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener().MergeValues(nil, strings.NewReader(sb.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestWriteValuesOmitContainers(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		expected string
+	}{
+		{
+			json:     `{}`,
+			expected: "root = {};\n",
+		},
+		{
+			json:     `{"a":{"b":[1,{"c":2}]},"d":[],"e":{}}`,
+			expected: "root.a.b[0] = 1;\nroot.a.b[1].c = 2;\nroot.d = [];\nroot.e = {};\n",
+		},
+		{
+			json:     `[[[]],{"0":[{}]}]`,
+			expected: "root[0][0] = [];\nroot[1][\"0\"][0] = {};\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, WithOmitContainers()).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+			expected, err := UnmarshalInput([]byte(tc.json), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener().MergeValues(nil, strings.NewReader(sb.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestWriteValuesAnnotations(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		options  []FlattenerOption
+		expected string
+	}{
+		{
+			json:     `{"a":[1,[]],"b":{"c":true}}`,
+			options:  []FlattenerOption{WithAnnotations(AnnotationsLength)},
+			expected: "root = {};\nroot.a = [];\nroot.a[0] = 1;\nroot.a[1] = [];\nroot.a[1].length = 0;\nroot.a.length = 2;\nroot.b = {};\nroot.b.c = true;\n",
+		},
+		{
+			json:     `{"a":[1,[]],"b":{"c":true}}`,
+			options:  []FlattenerOption{WithAnnotations(AnnotationsComment)},
+			expected: "root = {}; /* 2 properties */\nroot.a = []; /* 2 items */\nroot.a[0] = 1;\nroot.a[1] = []; /* 0 items */\nroot.b = {}; /* 1 property */\nroot.b.c = true;\n",
+		},
+		{
+			json:     `{"a":[1]}`,
+			options:  []FlattenerOption{WithAnnotations(AnnotationsLength), WithOmitContainers()},
+			expected: "root.a[0] = 1;\nroot.a.length = 1;\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, tc.options...).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+			expected, err := UnmarshalInput([]byte(tc.json), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener(DeepenerStrict()).MergeValues(nil, strings.NewReader(sb.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+			actual, err = NewDecoder(strings.NewReader(sb.String())).Decode(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestWriteValuesTemplateLiterals(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		expected string
+	}{
+		{
+			json:     `{"a":"b","c":"d\ne"}`,
+			expected: "root = {};\nroot.a = \"b\";\nroot.c = `d\ne`;\n",
+		},
+		{
+			json:     `"\tx\r\n${y} $z \\ ` + "`" + ` \u0001\n"`,
+			expected: "root = `\tx\\r\n\\${y} $z \\\\ \\` \\u0001\n`;\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, WithTemplateLiterals()).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+			expected, err := UnmarshalInput([]byte(tc.json), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener().MergeValues(nil, strings.NewReader(sb.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestDiffLinesTemplateLiterals(t *testing.T) {
+	flatten := func(value interface{}) []string {
+		sb := &strings.Builder{}
+		assert.NoError(t, NewFlattener(sb, WithTemplateLiterals()).WriteValue(value))
+		return SplitLines(sb.String())
+	}
+	a := flatten(map[string]interface{}{"script": "a = 1\nb = 2\nc = 3\n"})
+	b := flatten(map[string]interface{}{"script": "a = 1\nb = 4\nc = 3\n"})
+	assert.Equal(t, []OpCode{
+		{Tag: 'e', I1: 0, I2: 2, J1: 0, J2: 2},
+		{Tag: 'r', I1: 2, I2: 3, J1: 2, J2: 3},
+		{Tag: 'e', I1: 3, I2: 6, J1: 3, J2: 6},
+	}, DiffLines(a, b))
+}
+
+func TestWriteValuesEmbeddedJSON(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		options  []FlattenerOption
+		expected string
+	}{
+		{
+			json:     `{"a":"{\"b\":[1,\"{}\"]}","c":"[]\n","d":"{\"b\": 1}","e":"[1]x","f":"1"}`,
+			expected: "root = {};\nroot.a = {}; /* json */\nroot.a.b = [];\nroot.a.b[0] = 1;\nroot.a.b[1] = {}; /* json */\nroot.c = []; /* json + \"\\n\" */\nroot.d = \"{\\\"b\\\": 1}\";\nroot.e = \"[1]x\";\nroot.f = \"1\";\n",
+		},
+		{
+			json:     `{"a":"{\"b\":1,\"c\":{}}"}`,
+			options:  []FlattenerOption{WithOmitContainers(), WithAnnotations(AnnotationsComment)},
+			expected: "root.a = {}; /* json */\nroot.a.b = 1;\nroot.a.c = {}; /* 0 properties */\n",
+		},
+		{
+			json:     `{"a":"{\n  \"b\": [\n    1\n  ]\n}\n","c":"[\n\t\"\\u003c\\u0026\\u003e\"\n]","d":"{\"b\":\"<\"}","e":"{\"b\":1,\"a\":2}"}`,
+			expected: "root = {};\nroot.a = {}; /* json indent \"  \" + \"\\n\" */\nroot.a.b = [];\nroot.a.b[0] = 1;\nroot.c = []; /* json indent \"\\t\" */\nroot.c[0] = \"\\u003c\\u0026\\u003e\";\nroot.d = {}; /* json unescaped */\nroot.d.b = \"\\u003c\";\nroot.e = \"{\\\"b\\\":1,\\\"a\\\":2}\";\n",
+		},
+		{
+			json:     `{"a":"[1]","b":{"c":"[1]"}}`,
+			options:  []FlattenerOption{WithMaxDepth(2)},
+			expected: "root = {};\nroot.a = []; /* json */\nroot.a[0] = 1;\nroot.b = {};\nroot.b.c = \"[1]\";\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			options := append([]FlattenerOption{WithEmbeddedJSON()}, tc.options...)
+			assert.NoError(t, NewFlattener(sb, options...).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+			expected, err := UnmarshalInput([]byte(tc.json), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener(DeepenerStrict()).MergeValues(nil, strings.NewReader(sb.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+			actual, err = NewDecoder(strings.NewReader(sb.String())).Decode(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestWriteValuesUTF8(t *testing.T) {
+	for i, tc := range []struct {
+		json     string
+		options  []FlattenerOption
+		expected string
+	}{
+		{
+			json:     `{"données":"é😀\u0001\u2028","x":["é"]}`,
+			options:  []FlattenerOption{WithInlineScalarArrays()},
+			expected: "root = {};\nroot[\"données\"] = \"é😀\\u0001\\u2028\";\nroot.x = [\"é\"];\n",
+		},
+		{
+			json:     `{"données":"é😀\u0001\u2028","x":["é"]}`,
+			options:  []FlattenerOption{WithInlineScalarArrays(), WithUTF8()},
+			expected: "root = {};\nroot.données = \"é😀\\u0001\\u2028\";\nroot.x = [\"é\"];\n",
+		},
+		{
+			json:     `{"<":"é","a":"<&>\u2028"}`,
+			expected: "root = {};\nroot[\"<\"] = \"é\";\nroot.a = \"\\u003c\\u0026\\u003e\\u2028\";\n",
+		},
+		{
+			json:     `{"<":"é","a":"<&>\u2028"}`,
+			options:  []FlattenerOption{WithUTF8()},
+			expected: "root = {};\nroot[\"<\"] = \"é\";\nroot.a = \"<&>\\u2028\";\n",
+		},
+		{
+			json:     `"<\n>"`,
+			options:  []FlattenerOption{WithTemplateLiterals()},
+			expected: "root = `\\u003c\n\\u003e`;\n",
+		},
+		{
+			json:     `"<\n>"`,
+			options:  []FlattenerOption{WithTemplateLiterals(), WithUTF8()},
+			expected: "root = `<\n>`;\n",
+		},
+		{
+			json:     `"é\n😀"`,
+			options:  []FlattenerOption{WithTemplateLiterals()},
+			expected: "root = `é\n😀`;\n",
+		},
+		{
+			json:     `"é\n😀"`,
+			options:  []FlattenerOption{WithTemplateLiterals(), WithUTF8()},
+			expected: "root = `é\n😀`;\n",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, tc.options...).WriteValues([]byte(tc.json)))
+			assert.Equal(t, tc.expected, sb.String())
+			expected, err := UnmarshalInput([]byte(tc.json), InputFormatJSON)
+			assert.NoError(t, err)
+			actual, err := NewDeepener().MergeValues(nil, strings.NewReader(sb.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestPropertyAccessor(t *testing.T) {
+	for i, tc := range []struct {
+		name         string
+		expected     string
+		expectedUTF8 string
+	}{
+		{name: "a", expected: "root.a", expectedUTF8: "root.a"},
+		{name: "$ref", expected: "root.$ref", expectedUTF8: "root.$ref"},
+		{name: "_a$0", expected: "root._a$0", expectedUTF8: "root._a$0"},
+		{name: "données", expected: `root["données"]`, expectedUTF8: "root.données"},
+		{name: "日本語", expected: `root["日本語"]`, expectedUTF8: "root.日本語"},
+		{name: "", expected: `root[""]`, expectedUTF8: `root[""]`},
+		{name: "0a", expected: `root["0a"]`, expectedUTF8: `root["0a"]`},
+		{name: "a-b", expected: `root["a-b"]`, expectedUTF8: `root["a-b"]`},
+		{name: "a b", expected: `root["a b"]`, expectedUTF8: `root["a b"]`},
+		{name: "a€", expected: `root["a€"]`, expectedUTF8: `root["a€"]`},
+		{name: "true", expected: `root["true"]`, expectedUTF8: `root["true"]`},
+		{name: "a\"\n", expected: `root["a\"\n"]`, expectedUTF8: `root["a\"\n"]`},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for _, utf8Output := range []bool{false, true} {
+				expected := tc.expected
+				if utf8Output {
+					expected = tc.expectedUTF8
+				}
+				actual := propertyAccessor("root", tc.name, utf8Output)
+				assert.Equal(t, expected, actual)
+				identifier, properties, err := ParsePath(actual)
+				assert.NoError(t, err)
+				assert.Equal(t, "root", identifier)
+				assert.Equal(t, []interface{}{tc.name}, properties)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package flatjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// An InputFormat is a format of input.
+type InputFormat string
+
+// InputFormats.
+const (
+	InputFormatAuto   InputFormat = ""
+	InputFormatJSON   InputFormat = "json"
+	InputFormatFlat   InputFormat = "flat"
+	InputFormatNDJSON InputFormat = "ndjson"
+)
+
+// DetectInputFormat returns the format of data. data is JSON if it contains a
+// single JSON value, NDJSON if it contains several JSON values, and flat JSON
+// otherwise. data that starts with an object or an array but is not valid JSON
+// is JSON, so that parsing it reports the JSON syntax error.
+func DetectInputFormat(data []byte) InputFormat {
+	d := json.NewDecoder(bytes.NewReader(data))
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return InputFormatJSON
+		}
+		return InputFormatFlat
+	}
+	if _, err := d.Token(); errors.Is(err, io.EOF) {
+		return InputFormatJSON
+	}
+	return InputFormatNDJSON
+}
+
+// UnmarshalInput parses data in format and returns the resulting value. If
+// format is InputFormatAuto then the format is detected with
+// DetectInputFormat. NDJSON is returned as an array with one element for each
+// value. Numbers are returned as json.Numbers.
+func UnmarshalInput(data []byte, format InputFormat) (interface{}, error) {
+	if format == InputFormatAuto {
+		format = DetectInputFormat(data)
+	}
+	switch format {
+	case InputFormatJSON:
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		var value interface{}
+		if err := d.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case InputFormatFlat:
+		return NewDeepener().MergeValues(nil, bytes.NewReader(data))
+	case InputFormatNDJSON:
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		values := []interface{}{}
+		for {
+			var value interface{}
+			switch err := d.Decode(&value); {
+			case errors.Is(err, io.EOF):
+				return values, nil
+			case err != nil:
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown input format", format)
+	}
+}
@@ -0,0 +1,92 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestUnmarshalInput(t *testing.T) {
+	for i, tc := range []struct {
+		data           string
+		format         InputFormat
+		expectedFormat InputFormat
+		expected       interface{}
+		expectedErr    bool
+	}{
+		{
+			data:           `{"a":[1,true]}`,
+			expectedFormat: InputFormatJSON,
+			expected:       map[string]interface{}{"a": []interface{}{json.Number("1"), true}},
+		},
+		{
+			data:           " null\n",
+			expectedFormat: InputFormatJSON,
+			expected:       nil,
+		},
+		{
+			data:           "{\"a\":1}\n{\"a\":2}\n",
+			expectedFormat: InputFormatNDJSON,
+			expected: []interface{}{
+				map[string]interface{}{"a": json.Number("1")},
+				map[string]interface{}{"a": json.Number("2")},
+			},
+		},
+		{
+			data:           "root = {};\nroot.a = 1;\n",
+			expectedFormat: InputFormatFlat,
+			expected:       map[string]interface{}{"a": json.Number("1")},
+		},
+		{
+			data:           "true\n",
+			expectedFormat: InputFormatJSON,
+			expected:       true,
+		},
+		{
+			data:           "\n{\"a\":1,}\n",
+			expectedFormat: InputFormatJSON,
+			expectedErr:    true,
+		},
+		{
+			data:           "[1, 2",
+			expectedFormat: InputFormatJSON,
+			expectedErr:    true,
+		},
+		{
+			data:           "{} x",
+			expectedFormat: InputFormatNDJSON,
+			expectedErr:    true,
+		},
+		{
+			data:           `{"a":1}`,
+			format:         InputFormatNDJSON,
+			expectedFormat: InputFormatJSON,
+			expected:       []interface{}{map[string]interface{}{"a": json.Number("1")}},
+		},
+		{
+			data:           `{"a":1}`,
+			format:         InputFormatFlat,
+			expectedFormat: InputFormatJSON,
+			expectedErr:    true,
+		},
+		{
+			data:           `{"a":1}`,
+			format:         "yaml",
+			expectedFormat: InputFormatJSON,
+			expectedErr:    true,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expectedFormat, DetectInputFormat([]byte(tc.data)))
+			actual, err := UnmarshalInput([]byte(tc.data), tc.format)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
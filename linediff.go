@@ -0,0 +1,606 @@
+package flatjson
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// An OpCode describes how to turn the lines A[I1:I2] into the lines B[J1:J2].
+// Tag is 'e' if the lines are equal, 'r' if the lines of A are replaced by the
+// lines of B, 'd' if the lines of A are deleted, or 'i' if the lines of B are
+// inserted.
+type OpCode struct {
+	Tag byte
+	I1  int
+	I2  int
+	J1  int
+	J2  int
+}
+
+// A pathKey is the parsed path of a line of flattened JSON.
+type pathKey struct {
+	identifier string
+	properties []interface{}
+}
+
+// DiffLines returns the OpCodes that turn the lines a into the lines b.
+//
+// Flattened JSON is sorted by path, so if the paths of the assignments on
+// each side are strictly increasing then the assignments are merge-joined on
+// their paths in linear time and memory. Equal assignments with equal paths
+// anchor the diff, and the lines between anchors that are not assignments,
+// like the continuation lines of template literals, are diffed with Myers'
+// linear space diff algorithm. Otherwise, DiffLines falls back to Myers'
+// algorithm for all lines.
+func DiffLines(a, b []string) []OpCode {
+	if keysA, ok := sortedPathKeys(a); ok {
+		if keysB, ok := sortedPathKeys(b); ok {
+			return mergeJoinLines(a, b, keysA, keysB)
+		}
+	}
+	return myersDiffLines(a, b)
+}
+
+// DiffFlat returns the changes between the flattened JSON read from a and b,
+// one for each path whose assignment was added, removed, or changed. Later
+// assignments to a path override earlier ones. Changes are returned in path
+// order.
+func DiffFlat(a, b io.Reader) ([]Change, error) {
+	assignmentsA, err := sortedAssignments(a)
+	if err != nil {
+		return nil, err
+	}
+	assignmentsB, err := sortedAssignments(b)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	i, j := 0, 0
+	for i < len(assignmentsA) || j < len(assignmentsB) {
+		c := 0
+		switch {
+		case j == len(assignmentsB):
+			c = -1
+		case i == len(assignmentsA):
+			c = 1
+		default:
+			c = comparePathKeys(&assignmentsA[i].key, &assignmentsB[j].key)
+		}
+		switch c {
+		case -1:
+			changes = append(changes, Change{
+				Op:   ChangeRemove,
+				Path: assignmentsA[i].path(),
+				Old:  assignmentsA[i].value,
+			})
+			i++
+		case 1:
+			changes = append(changes, Change{
+				Op:   ChangeAdd,
+				Path: assignmentsB[j].path(),
+				New:  assignmentsB[j].value,
+			})
+			j++
+		default:
+			if !reflect.DeepEqual(assignmentsA[i].value, assignmentsB[j].value) {
+				changes = append(changes, Change{
+					Op:   ChangeReplace,
+					Path: assignmentsB[j].path(),
+					Old:  assignmentsA[i].value,
+					New:  assignmentsB[j].value,
+				})
+			}
+			i++
+			j++
+		}
+	}
+	return changes, nil
+}
+
+// comparePathKeys compares the paths x and y in the order in which they are
+// written by a Flattener, returning -1, 0, or 1 if x is before, the same as,
+// or after y. Array indexes are compared numerically and sort before property
+// names.
+func comparePathKeys(x, y *pathKey) int {
+	if c := strings.Compare(x.identifier, y.identifier); c != 0 {
+		return c
+	}
+	for i := 0; i < len(x.properties) && i < len(y.properties); i++ {
+		switch px := x.properties[i].(type) {
+		case int:
+			switch py := y.properties[i].(type) {
+			case int:
+				switch {
+				case px < py:
+					return -1
+				case px > py:
+					return 1
+				}
+			case string:
+				return -1
+			}
+		case string:
+			switch py := y.properties[i].(type) {
+			case int:
+				return 1
+			case string:
+				if c := strings.Compare(px, py); c != 0 {
+					return c
+				}
+			}
+		}
+	}
+	switch {
+	case len(x.properties) < len(y.properties):
+		return -1
+	case len(x.properties) > len(y.properties):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// groupOpCodes groups opCodes into hunks with up to context lines of context.
+func groupOpCodes(opCodes []OpCode, context int) [][]OpCode {
+	if len(opCodes) == 0 {
+		return nil
+	}
+	opCodes = append([]OpCode(nil), opCodes...)
+	if first := &opCodes[0]; first.Tag == 'e' {
+		first.I1 = max(first.I1, first.I2-context)
+		first.J1 = max(first.J1, first.J2-context)
+	}
+	if last := &opCodes[len(opCodes)-1]; last.Tag == 'e' {
+		last.I2 = min(last.I2, last.I1+context)
+		last.J2 = min(last.J2, last.J1+context)
+	}
+	var groups [][]OpCode
+	var group []OpCode
+	for _, opCode := range opCodes {
+		if opCode.Tag == 'e' && opCode.I2-opCode.I1 > 2*context {
+			group = append(group, OpCode{
+				Tag: 'e',
+				I1:  opCode.I1,
+				I2:  min(opCode.I2, opCode.I1+context),
+				J1:  opCode.J1,
+				J2:  min(opCode.J2, opCode.J1+context),
+			})
+			groups = append(groups, group)
+			group = nil
+			opCode.I1 = max(opCode.I1, opCode.I2-context)
+			opCode.J1 = max(opCode.J1, opCode.J2-context)
+		}
+		group = append(group, opCode)
+	}
+	if len(group) > 0 && (len(group) != 1 || group[0].Tag != 'e') {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// mergeJoinLines returns the OpCodes that turn a into b, given that keysA and
+// keysB are the paths of the lines of a and b, with an empty identifier for
+// lines that are not assignments, and that the paths of the assignments are
+// strictly increasing.
+func mergeJoinLines(a, b []string, keysA, keysB []pathKey) []OpCode {
+	var opCodes opCodeBuilder
+	i0, j0 := 0, 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case keysA[i].identifier == "":
+			i++
+		case keysB[j].identifier == "":
+			j++
+		default:
+			switch comparePathKeys(&keysA[i], &keysB[j]) {
+			case -1:
+				i++
+			case 1:
+				j++
+			default:
+				if a[i] == b[j] {
+					diffGapLines(&opCodes, a[i0:i], b[j0:j], keysA[i0:i], keysB[j0:j])
+					opCodes.add('e', 1, 1)
+					i0, j0 = i+1, j+1
+				}
+				i++
+				j++
+			}
+		}
+	}
+	diffGapLines(&opCodes, a[i0:], b[j0:], keysA[i0:], keysB[j0:])
+	return opCodes.opCodes
+}
+
+// diffGapLines adds the OpCodes that turn a into b to opCodes, where a and b
+// are the lines between two anchors. After their common prefix and suffix, if
+// all the lines are assignments then none of them are equal, because equal
+// assignments with equal paths are anchors, so a is replaced by b. Otherwise,
+// the lines are diffed with Myers' algorithm.
+func diffGapLines(opCodes *opCodeBuilder, a, b []string, keysA, keysB []pathKey) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	opCodes.add('e', prefix, prefix)
+	a, keysA = a[prefix:len(a)-suffix], keysA[prefix:len(keysA)-suffix]
+	b, keysB = b[prefix:len(b)-suffix], keysB[prefix:len(keysB)-suffix]
+	if allPathKeys(keysA) && allPathKeys(keysB) {
+		opCodes.add('r', len(a), len(b))
+	} else {
+		for _, opCode := range myersDiffLines(a, b) {
+			opCodes.add(opCode.Tag, opCode.I2-opCode.I1, opCode.J2-opCode.J1)
+		}
+	}
+	opCodes.add('e', suffix, suffix)
+}
+
+// allPathKeys returns whether every key in keys is the path of an assignment.
+func allPathKeys(keys []pathKey) bool {
+	for i := range keys {
+		if keys[i].identifier == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// myersDiffLines returns the OpCodes that turn a into b using Myers' linear
+// space diff algorithm.
+func myersDiffLines(a, b []string) []OpCode {
+	// Intern lines so that they can be compared as integers.
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		result := make([]int, 0, len(lines))
+		for _, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			result = append(result, id)
+		}
+		return result
+	}
+	m := &myers{
+		a:        intern(a),
+		b:        intern(b),
+		matchedA: make([]bool, len(a)),
+		matchedB: make([]bool, len(b)),
+	}
+	m.diff(0, len(a), 0, len(b))
+
+	var opCodes opCodeBuilder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && m.matchedA[i] && m.matchedB[j] {
+			opCodes.add('e', 1, 1)
+			i++
+			j++
+			continue
+		}
+		deleted, inserted := 0, 0
+		for i+deleted < len(a) && !m.matchedA[i+deleted] {
+			deleted++
+		}
+		for j+inserted < len(b) && !m.matchedB[j+inserted] {
+			inserted++
+		}
+		opCodes.add('r', deleted, inserted)
+		i += deleted
+		j += inserted
+	}
+	return opCodes.opCodes
+}
+
+// parseLinePathKey parses the path at the start of line. It is a fast path
+// for parser.parsePath that handles the paths written by a Flattener and
+// returns false for anything else.
+func parseLinePathKey(line string) (pathKey, bool) {
+	i := scanLineIdentifier(line, 0)
+	if i == 0 {
+		return pathKey{}, false
+	}
+	key := pathKey{
+		identifier: line[:i],
+	}
+	for i < len(line) {
+		switch {
+		case strings.HasPrefix(line[i:], " = "):
+			return key, !keywords[key.identifier]
+		case line[i] == '.':
+			start := i + 1
+			i = scanLineIdentifier(line, start)
+			if i == start || keywords[line[start:i]] {
+				return pathKey{}, false
+			}
+			key.properties = append(key.properties, line[start:i])
+		case strings.HasPrefix(line[i:], "[\""):
+			r := strings.NewReader(line[i+1:])
+			tok, property := newScanner(r).scanString()
+			if tok != tokenString {
+				return pathKey{}, false
+			}
+			i = len(line) - r.Len()
+			if i == len(line) || line[i] != ']' {
+				return pathKey{}, false
+			}
+			key.properties = append(key.properties, property)
+			i++
+		case line[i] == '[':
+			i++
+			start := i
+			for i < len(line) && '0' <= line[i] && line[i] <= '9' {
+				i++
+			}
+			if i == start || i == len(line) || line[i] != ']' || (line[start] == '0' && i-start > 1) {
+				return pathKey{}, false
+			}
+			index, err := strconv.Atoi(line[start:i])
+			if err != nil {
+				return pathKey{}, false
+			}
+			key.properties = append(key.properties, index)
+			i++
+		default:
+			return pathKey{}, false
+		}
+	}
+	return pathKey{}, false
+}
+
+// scanLineIdentifier returns the end of the ECMAScript identifier name that
+// starts at line[start:], or start if there is none.
+func scanLineIdentifier(line string, start int) int {
+	i := start
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if (i == start && !isIdentifierStart(r)) || (i > start && !isIdentifierPart(r)) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// A keyedAssignment is an assignment with its path key.
+type keyedAssignment struct {
+	key   pathKey
+	utf8  bool
+	value interface{}
+}
+
+// path returns the path of a in the notation that it was written in.
+func (a *keyedAssignment) path() string {
+	return formatPath(a.key.identifier, a.key.properties, a.utf8)
+}
+
+// sortedAssignments reads assignments from r and returns them sorted by path,
+// keeping only the last assignment to each path. Delete and append statements
+// are not supported.
+func sortedAssignments(r io.Reader) ([]keyedAssignment, error) {
+	assignments, err := newParser(r).parseAssignments()
+	if err != nil {
+		return nil, err
+	}
+	keyedAssignments := make([]keyedAssignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		if assignment.op != assignmentSet {
+			return nil, fmt.Errorf("%s: delete and append statements cannot be diffed", FormatPath(assignment.identifier, assignment.properties))
+		}
+		keyedAssignments = append(keyedAssignments, keyedAssignment{
+			key: pathKey{
+				identifier: assignment.identifier,
+				properties: assignment.properties,
+			},
+			utf8:  assignment.utf8,
+			value: assignment.value,
+		})
+	}
+	sort.SliceStable(keyedAssignments, func(i, j int) bool {
+		return comparePathKeys(&keyedAssignments[i].key, &keyedAssignments[j].key) < 0
+	})
+	result := keyedAssignments[:0]
+	for _, keyedAssignment := range keyedAssignments {
+		if n := len(result); n > 0 && comparePathKeys(&result[n-1].key, &keyedAssignment.key) == 0 {
+			result[n-1] = keyedAssignment
+		} else {
+			result = append(result, keyedAssignment)
+		}
+	}
+	return result, nil
+}
+
+// sortedPathKeys returns the paths of lines, with an empty identifier for
+// lines that are not assignments, and whether the paths of the assignments are
+// strictly increasing.
+func sortedPathKeys(lines []string) ([]pathKey, bool) {
+	keys := make([]pathKey, len(lines))
+	var prev *pathKey
+	for i, line := range lines {
+		key, ok := parseLinePathKey(line)
+		if !ok {
+			identifier, properties, err := parseAssignmentPath(line)
+			if err != nil {
+				continue
+			}
+			key = pathKey{
+				identifier: identifier,
+				properties: properties,
+			}
+		}
+		keys[i] = key
+		if prev != nil && comparePathKeys(prev, &keys[i]) >= 0 {
+			return nil, false
+		}
+		prev = &keys[i]
+	}
+	return keys, true
+}
+
+// An opCodeBuilder builds a slice of OpCodes, coalescing adjacent changes.
+type opCodeBuilder struct {
+	opCodes []OpCode
+	i       int
+	j       int
+}
+
+// add adds an OpCode with tag that covers the next m lines of A and the next
+// n lines of B. Tags other than 'e' are normalized according to m and n.
+func (b *opCodeBuilder) add(tag byte, m, n int) {
+	if m == 0 && n == 0 {
+		return
+	}
+	if k := len(b.opCodes) - 1; k >= 0 && (b.opCodes[k].Tag == 'e') == (tag == 'e') {
+		b.opCodes[k].I2 += m
+		b.opCodes[k].J2 += n
+		if tag != 'e' {
+			b.opCodes[k].Tag = changeTag(b.opCodes[k].I2-b.opCodes[k].I1, b.opCodes[k].J2-b.opCodes[k].J1)
+		}
+	} else {
+		if tag != 'e' {
+			tag = changeTag(m, n)
+		}
+		b.opCodes = append(b.opCodes, OpCode{
+			Tag: tag,
+			I1:  b.i,
+			I2:  b.i + m,
+			J1:  b.j,
+			J2:  b.j + n,
+		})
+	}
+	b.i += m
+	b.j += n
+}
+
+// changeTag returns the tag of a change that deletes m lines and inserts n
+// lines.
+func changeTag(m, n int) byte {
+	switch {
+	case n == 0:
+		return 'd'
+	case m == 0:
+		return 'i'
+	default:
+		return 'r'
+	}
+}
+
+// A myers computes the lines common to a and b using the linear space
+// variant of Myers' diff algorithm, as described in "An O(ND) Difference
+// Algorithm and Its Variations" by Eugene W. Myers.
+type myers struct {
+	a        []int
+	b        []int
+	matchedA []bool
+	matchedB []bool
+}
+
+// diff marks the lines common to a[aLo:aHi] and b[bLo:bHi].
+func (m *myers) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && m.a[aLo] == m.b[bLo] {
+		m.matchedA[aLo] = true
+		m.matchedB[bLo] = true
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && m.a[aHi-1] == m.b[bHi-1] {
+		m.matchedA[aHi-1] = true
+		m.matchedB[bHi-1] = true
+		aHi--
+		bHi--
+	}
+	if aLo == aHi || bLo == bHi {
+		return
+	}
+	if x, y, ok := m.bisect(aLo, aHi, bLo, bHi); ok {
+		m.diff(aLo, x, bLo, y)
+		m.diff(x, aHi, y, bHi)
+	}
+}
+
+// bisect finds the point at which the forward and reverse searches for the
+// shortest edit script of a[aLo:aHi] and b[bLo:bHi] overlap. It returns false
+// if a[aLo:aHi] and b[bLo:bHi] have no lines in common.
+func (m *myers) bisect(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, mm := aHi-aLo, bHi-bLo
+	maxD := (n + mm + 1) / 2
+	offset := maxD + 1
+	length := 2*maxD + 3
+	vf := make([]int, length)
+	vb := make([]int, length)
+	for k := range vf {
+		vf[k] = -1
+		vb[k] = -1
+	}
+	vf[offset+1] = 0
+	vb[offset+1] = 0
+	delta := n - mm
+	front := delta%2 != 0
+	kfStart, kfEnd, kbStart, kbEnd := 0, 0, 0, 0
+	for d := range maxD {
+		for kf := -d + kfStart; kf <= d-kfEnd; kf += 2 {
+			var x int
+			if kf == -d || (kf != d && vf[offset+kf-1] < vf[offset+kf+1]) {
+				x = vf[offset+kf+1]
+			} else {
+				x = vf[offset+kf-1] + 1
+			}
+			y := x - kf
+			for x < n && y < mm && m.a[aLo+x] == m.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+kf] = x
+			switch {
+			case x > n:
+				kfEnd += 2
+			case y > mm:
+				kfStart += 2
+			case front:
+				if kb := offset + delta - kf; 0 <= kb && kb < length && vb[kb] != -1 && x >= n-vb[kb] {
+					return aLo + x, bLo + y, true
+				}
+			}
+		}
+		for kb := -d + kbStart; kb <= d-kbEnd; kb += 2 {
+			var x int
+			if kb == -d || (kb != d && vb[offset+kb-1] < vb[offset+kb+1]) {
+				x = vb[offset+kb+1]
+			} else {
+				x = vb[offset+kb-1] + 1
+			}
+			y := x - kb
+			for x < n && y < mm && m.a[aHi-x-1] == m.b[bHi-y-1] {
+				x++
+				y++
+			}
+			vb[offset+kb] = x
+			switch {
+			case x > n:
+				kbEnd += 2
+			case y > mm:
+				kbStart += 2
+			case !front:
+				if kf := offset + delta - kb; 0 <= kf && kf < length && vf[kf] != -1 {
+					xf := vf[kf]
+					yf := offset + xf - kf
+					if xf >= n-x {
+						return aLo + xf, bLo + yf, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
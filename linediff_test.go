@@ -0,0 +1,388 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+func TestDiffLines(t *testing.T) {
+	for i, tc := range []struct {
+		a        string
+		b        string
+		expected []OpCode
+	}{
+		{
+			a: "",
+			b: "",
+			expected: []OpCode{
+				{Tag: 'e', I1: 0, I2: 1, J1: 0, J2: 1},
+			},
+		},
+		{
+			a: "root = 0;\n",
+			b: "root = 0;\n",
+			expected: []OpCode{
+				{Tag: 'e', I1: 0, I2: 2, J1: 0, J2: 2},
+			},
+		},
+		{
+			a: "root = {};\nroot.a = 0;\nroot.b = 1;\nroot.d = 3;\n",
+			b: "root = {};\nroot.b = 2;\nroot.c = 3;\nroot.d = 3;\n",
+			expected: []OpCode{
+				{Tag: 'e', I1: 0, I2: 1, J1: 0, J2: 1},
+				{Tag: 'r', I1: 1, I2: 3, J1: 1, J2: 3},
+				{Tag: 'e', I1: 3, I2: 5, J1: 3, J2: 5},
+			},
+		},
+		{
+			a: "root = [];\nroot[9] = 0;\nroot[10] = 0;\n",
+			b: "root = [];\nroot[10] = 0;\n",
+			expected: []OpCode{
+				{Tag: 'e', I1: 0, I2: 1, J1: 0, J2: 1},
+				{Tag: 'd', I1: 1, I2: 2, J1: 1, J2: 1},
+				{Tag: 'e', I1: 2, I2: 4, J1: 1, J2: 3},
+			},
+		},
+		{
+			a: "a\nb\nc\n",
+			b: "a\nc\nd\n",
+			expected: []OpCode{
+				{Tag: 'e', I1: 0, I2: 1, J1: 0, J2: 1},
+				{Tag: 'd', I1: 1, I2: 2, J1: 1, J2: 1},
+				{Tag: 'e', I1: 2, I2: 3, J1: 1, J2: 2},
+				{Tag: 'i', I1: 3, I2: 3, J1: 2, J2: 3},
+				{Tag: 'e', I1: 3, I2: 4, J1: 3, J2: 4},
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, DiffLines(SplitLines(tc.a), SplitLines(tc.b)))
+		})
+	}
+}
+
+func TestDiffFlat(t *testing.T) {
+	for i, tc := range []struct {
+		a        string
+		b        string
+		expected []Change
+	}{
+		{
+			a: "root = 0;\n",
+			b: "root = 0;\n",
+		},
+		{
+			a: "root = {};\nroot.a = 1;\nroot.b = true;\nroot[\"c.d\"] = \"x\";\n",
+			b: "root = {};\nroot.a = 1.0;\nroot[\"c.d\"] = \"y\";\nroot.e = [];\n",
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.a", Old: json.Number("1"), New: json.Number("1.0")},
+				{Op: ChangeRemove, Path: "root.b", Old: true},
+				{Op: ChangeReplace, Path: "root[\"c.d\"]", Old: "x", New: "y"},
+				{Op: ChangeAdd, Path: "root.e", New: []interface{}{}},
+			},
+		},
+		{
+			a: "root.données.a = 1;\nroot[\"é\"] = 1;\n",
+			b: "root.données.a = 2;\nroot[\"é\"] = 2;\n",
+			expected: []Change{
+				{Op: ChangeReplace, Path: "root.données.a", Old: json.Number("1"), New: json.Number("2")},
+				{Op: ChangeReplace, Path: "root[\"é\"]", Old: json.Number("1"), New: json.Number("2")},
+			},
+		},
+		{
+			a: "root[10] = 0;\nroot = [];\nroot[9] = 0;\n",
+			b: "root = [];\nroot[9] = 1;\nroot[9] = 0;\n",
+			expected: []Change{
+				{Op: ChangeRemove, Path: "root[10]", Old: json.Number("0")},
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := DiffFlat(strings.NewReader(tc.a), strings.NewReader(tc.b))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParseLinePathKey(t *testing.T) {
+	for i, tc := range []struct {
+		line           string
+		expectedParsed bool
+	}{
+		{line: "root = 0;\n", expectedParsed: true},
+		{line: "root.a.b_0 = 0;\n", expectedParsed: true},
+		{line: "root[0][12] = 0;\n", expectedParsed: true},
+		{line: "root[\"a.b\"][\"\\\"]\"] = 0;\n", expectedParsed: true},
+		{line: "root.données.日本語 = 0;\n", expectedParsed: true},
+		{line: "root.$ref.é$_0 = 0;\n", expectedParsed: true},
+		{line: "$.a = 0;\n", expectedParsed: true},
+		{line: "root[\"données\"][\"😀\"] = 0;\n", expectedParsed: true},
+		{line: "root[\"\\u00e9\"][\"\\ud83d\\ude00\"] = 0;\n", expectedParsed: true},
+		{line: "root.a", expectedParsed: false},
+		{line: "root.€ = 0;\n", expectedParsed: false},
+		{line: "root.a=0;", expectedParsed: false},
+		{line: "root.true = 0;\n", expectedParsed: false},
+		{line: "null = 0;\n", expectedParsed: false},
+		{line: "root[01] = 0;\n", expectedParsed: false},
+		{line: "root[\"a\" = 0;\n", expectedParsed: false},
+		{line: "/* comment */ root = 0;\n", expectedParsed: false},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			key, ok := parseLinePathKey(tc.line)
+			assert.Equal(t, tc.expectedParsed, ok)
+			if ok {
+				identifier, properties, err := parseAssignmentPath(tc.line)
+				assert.NoError(t, err)
+				assert.Equal(t, pathKey{identifier: identifier, properties: properties}, key)
+			}
+		})
+	}
+}
+
+func TestDiffLinesUnicode(t *testing.T) {
+	value := map[string]interface{}{
+		"$ref":    "a",
+		"a":       map[string]interface{}{"$": 1.0, "é": 2.0},
+		"données": []interface{}{"b", "c"},
+		"日本語":     "d",
+		"😀":       "e",
+		"a-b":     "f",
+	}
+	for i, options := range [][]FlattenerOption{nil, {WithUTF8()}} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, NewFlattener(sb, options...).WriteValue(value))
+			a := SplitLines(sb.String())
+			b := append(append([]string{}, a[:3]...), a[4:]...)
+			for _, line := range a[:len(a)-1] {
+				_, ok := parseLinePathKey(line)
+				assert.True(t, ok, line)
+			}
+			keysA, ok := sortedPathKeys(a)
+			assert.True(t, ok)
+			keysB, ok := sortedPathKeys(b)
+			assert.True(t, ok)
+			assert.Equal(t, mergeJoinLines(a, b, keysA, keysB), DiffLines(a, b))
+			assert.Equal(t, []OpCode{
+				{Tag: 'e', I1: 0, I2: 3, J1: 0, J2: 3},
+				{Tag: 'd', I1: 3, I2: 4, J1: 3, J2: 3},
+				{Tag: 'e', I1: 4, I2: len(a), J1: 3, J2: len(b)},
+			}, DiffLines(a, b))
+		})
+	}
+}
+
+func TestMyersDiffLines(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, r.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+		return lines
+	}
+	for i := range 1000 {
+		a, b := randomLines(), randomLines()
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			opCodes := myersDiffLines(a, b)
+			var actualB []string
+			common := 0
+			i, j := 0, 0
+			for _, opCode := range opCodes {
+				assert.Equal(t, i, opCode.I1)
+				assert.Equal(t, j, opCode.J1)
+				if opCode.Tag == 'e' {
+					assert.Equal(t, a[opCode.I1:opCode.I2], b[opCode.J1:opCode.J2])
+					common += opCode.I2 - opCode.I1
+				}
+				actualB = append(actualB, b[opCode.J1:opCode.J2]...)
+				i, j = opCode.I2, opCode.J2
+			}
+			assert.Equal(t, len(a), i)
+			assert.Equal(t, len(b), j)
+			assert.Equal(t, strings.Join(b, ""), strings.Join(actualB, ""))
+			assert.Equal(t, longestCommonSubsequenceLength(a, b), common)
+		})
+	}
+}
+
+func TestMergeJoinLines(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomLines := func(continuations bool) []string {
+		lines := []string{"root = [];\n"}
+		for i := range 12 {
+			if r.Intn(2) == 0 {
+				continue
+			}
+			if continuations && r.Intn(2) == 0 {
+				lines = append(lines, fmt.Sprintf("root[%d] = `%c\n", i, 'a'+r.Intn(2)), fmt.Sprintf("%c`;\n", 'a'+r.Intn(2)))
+			} else {
+				lines = append(lines, fmt.Sprintf("root[%d] = %d;\n", i, r.Intn(2)))
+			}
+		}
+		return lines
+	}
+	for i := range 1000 {
+		continuations := i%2 == 1
+		a, b := randomLines(continuations), randomLines(continuations)
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			keysA, ok := sortedPathKeys(a)
+			assert.True(t, ok)
+			keysB, ok := sortedPathKeys(b)
+			assert.True(t, ok)
+			opCodes := mergeJoinLines(a, b, keysA, keysB)
+			var actualB []string
+			common := 0
+			i, j := 0, 0
+			for _, opCode := range opCodes {
+				assert.Equal(t, i, opCode.I1)
+				assert.Equal(t, j, opCode.J1)
+				if opCode.Tag == 'e' {
+					assert.Equal(t, a[opCode.I1:opCode.I2], b[opCode.J1:opCode.J2])
+					common += opCode.I2 - opCode.I1
+				}
+				actualB = append(actualB, b[opCode.J1:opCode.J2]...)
+				i, j = opCode.I2, opCode.J2
+			}
+			assert.Equal(t, len(a), i)
+			assert.Equal(t, len(b), j)
+			assert.Equal(t, strings.Join(b, ""), strings.Join(actualB, ""))
+			if !continuations {
+				assert.Equal(t, longestCommonSubsequenceLength(a, b), common)
+			}
+		})
+	}
+}
+
+func TestGroupOpCodes(t *testing.T) {
+	a := SplitLines("a\nb\nc\nd\ne\nf\ng\nh\ni\n")
+	b := SplitLines("a\nB\nc\nd\ne\nf\ng\nH\ni\n")
+	expected := difflib.NewMatcher(a, b).GetGroupedOpCodes(1)
+	actual := groupOpCodes(DiffLines(a, b), 1)
+	assert.Equal(t, len(expected), len(actual))
+	for i, group := range expected {
+		assert.Equal(t, len(group), len(actual[i]))
+		for j, opCode := range group {
+			assert.Equal(t, OpCode{Tag: opCode.Tag, I1: opCode.I1, I2: opCode.I2, J1: opCode.J1, J2: opCode.J2}, actual[i][j])
+		}
+	}
+}
+
+func BenchmarkDiffLines(b *testing.B) {
+	for _, shift := range []bool{false, true} {
+		linesA, linesB := benchmarkLines(b, 5000, shift, false)
+		name := "edits"
+		if shift {
+			name = "shifted"
+		}
+		b.Run(name+"/DiffLines", func(b *testing.B) {
+			for range b.N {
+				DiffLines(linesA, linesB)
+			}
+		})
+		b.Run(name+"/Myers", func(b *testing.B) {
+			for range b.N {
+				myersDiffLines(linesA, linesB)
+			}
+		})
+		b.Run(name+"/SequenceMatcher", func(b *testing.B) {
+			for range b.N {
+				difflib.NewMatcher(linesA, linesB).GetOpCodes()
+			}
+		})
+	}
+}
+
+// BenchmarkDiffLinesLarge benchmarks DiffLines on about 200,000 lines, where
+// Myers' algorithm and SequenceMatcher are too slow to compare.
+func BenchmarkDiffLinesLarge(b *testing.B) {
+	for _, shift := range []bool{false, true} {
+		for _, templateLiterals := range []bool{false, true} {
+			linesA, linesB := benchmarkLines(b, 66000, shift, templateLiterals)
+			name := "edits"
+			if shift {
+				name = "shifted"
+			}
+			if templateLiterals {
+				name += "/templateLiterals"
+			}
+			b.Run(name, func(b *testing.B) {
+				for range b.N {
+					DiffLines(linesA, linesB)
+				}
+			})
+		}
+	}
+}
+
+// benchmarkLines returns the flattened lines of two arrays of n objects. About
+// one percent of the elements of the second array are changed and ten
+// elements are appended to it. If shift is true then the first element of the
+// second array is also removed, which changes the path of every following
+// line. If templateLiterals is true then values are multiline strings written
+// as template literals.
+func benchmarkLines(b *testing.B, n int, shift, templateLiterals bool) ([]string, []string) {
+	b.Helper()
+	r := rand.New(rand.NewSource(1))
+	var values [2][]interface{}
+	for i := range n {
+		element := map[string]interface{}{
+			"id":    strconv.Itoa(i),
+			"value": fmt.Sprintf("value\n%d", r.Intn(10)),
+		}
+		values[0] = append(values[0], element)
+		if r.Intn(100) == 0 {
+			element = map[string]interface{}{
+				"id":    strconv.Itoa(i),
+				"value": "changed",
+			}
+		}
+		values[1] = append(values[1], element)
+	}
+	for i := range 10 {
+		values[1] = append(values[1], map[string]interface{}{
+			"id": strconv.Itoa(n + i),
+		})
+	}
+	if shift {
+		values[1] = values[1][1:]
+	}
+	var lines [2][]string
+	for i, value := range values {
+		sb := &strings.Builder{}
+		var options []FlattenerOption
+		if templateLiterals {
+			options = append(options, WithTemplateLiterals())
+		}
+		assert.NoError(b, NewFlattener(sb, options...).WriteValue(value))
+		lines[i] = SplitLines(sb.String())
+	}
+	return lines[0], lines[1]
+}
+
+// longestCommonSubsequenceLength returns the length of the longest common
+// subsequence of a and b.
+func longestCommonSubsequenceLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	return lengths[0][0]
+}
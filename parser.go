@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
 type unexpectedError struct {
@@ -32,12 +33,25 @@ type parser struct {
 		lit string
 		n   int
 	}
+	utf8Path bool // whether the last path wrote a non-ASCII identifier with dot notation
 }
 
+// An assignmentOp is the kind of an assignment statement.
+type assignmentOp int
+
+const (
+	assignmentSet    assignmentOp = iota // path = value;
+	assignmentDelete                     // delete path;
+	assignmentAppend                     // path[] = value;
+)
+
 type assignment struct {
+	op         assignmentOp
 	identifier string
 	properties []interface{}
+	utf8       bool // whether the path writes a non-ASCII identifier with dot notation
 	value      interface{}
+	comment    string // comment on the same line after the semicolon
 }
 
 func newParser(r io.Reader) *parser {
@@ -46,32 +60,93 @@ func newParser(r io.Reader) *parser {
 	}
 }
 
+// ParsePath parses path, for example root.menu["id"][0], into its identifier
+// and properties. Properties are strings for property names and ints for
+// array indexes.
+func ParsePath(path string) (string, []interface{}, error) {
+	p := newParser(strings.NewReader(path))
+	identifier, properties, err := p.parsePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != tokenEOF {
+		return "", nil, newErrUnexpected(tok, lit, tokenEOF)
+	}
+	return identifier, properties, nil
+}
+
+// ParseAssignment parses a single assignment, like `root.a = 1`, with an
+// optional trailing semicolon, and returns its path and value.
+func ParseAssignment(s string) (string, interface{}, error) {
+	p := newParser(strings.NewReader(s))
+	identifier, properties, err := p.parsePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token('=') {
+		return "", nil, newErrUnexpected(tok, lit, token('='))
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return "", nil, err
+	}
+	tok, lit := p.scanIgnoreWhitespaceAndComments()
+	if tok == token(';') {
+		tok, lit = p.scanIgnoreWhitespaceAndComments()
+	}
+	if tok != tokenEOF {
+		return "", nil, newErrUnexpected(tok, lit, token(';'), tokenEOF)
+	}
+	return FormatPath(identifier, properties), value, nil
+}
+
+// parseAssignmentPath parses the path of the assignment at the start of s.
+func parseAssignmentPath(s string) (string, []interface{}, error) {
+	p := newParser(strings.NewReader(s))
+	identifier, properties, err := p.parsePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token('=') {
+		return "", nil, newErrUnexpected(tok, lit, token('='))
+	}
+	return identifier, properties, nil
+}
+
+// parseAssignment parses a statement, which is an assignment, an append like
+// `root.items[] = value;`, or a deletion like `delete root.a;`. delete is not
+// reserved, so `delete.a = value;` assigns to the identifier delete.
 func (p *parser) parseAssignment() (*assignment, error) {
 	tok, lit := p.scanIgnoreWhitespaceAndComments()
 	if tok != tokenIdentifier {
-		p.unscan()
 		return nil, newErrUnexpected(tok, lit, tokenIdentifier)
 	}
 	identifier := lit
-	var properties []interface{}
-FOR:
-	for {
-		tok, lit := p.scanIgnoreWhitespaceAndComments()
-		switch {
-		case tok == token('='):
-			p.unscan()
-			break FOR
-		case tok == token('.') || tok == token('['):
+	if identifier == "delete" {
+		if tok, _ := p.scanIgnoreWhitespaceAndComments(); tok == tokenIdentifier {
 			p.unscan()
-			property, err := p.parsePropertyAccess()
+			identifier, properties, err := p.parsePath()
 			if err != nil {
 				return nil, err
 			}
-			properties = append(properties, property)
-		default:
-			return nil, newErrUnexpected(tok, lit, token('='), token('.'), token('['))
+			if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token(';') {
+				return nil, newErrUnexpected(tok, lit, token(';'))
+			}
+			return &assignment{
+				op:         assignmentDelete,
+				identifier: identifier,
+				properties: properties,
+				utf8:       p.utf8Path,
+				comment:    p.scanTrailingComment(),
+			}, nil
 		}
+		p.unscan()
 	}
+	properties, appending, err := p.parseProperties(true)
+	if err != nil {
+		return nil, err
+	}
+	utf8Path := p.utf8Path
 	tok, lit = p.scanIgnoreWhitespaceAndComments()
 	if tok != token('=') {
 		return nil, newErrUnexpected(tok, lit, token('='))
@@ -84,13 +159,34 @@ FOR:
 	if tok != token(';') {
 		return nil, newErrUnexpected(tok, lit, token(';'))
 	}
+	op := assignmentSet
+	if appending {
+		op = assignmentAppend
+	}
 	return &assignment{
+		op:         op,
 		identifier: identifier,
 		properties: properties,
+		utf8:       utf8Path,
 		value:      value,
+		comment:    p.scanTrailingComment(),
 	}, nil
 }
 
+// scanTrailingComment returns the comment that follows on the same line, or
+// the empty string if there is no such comment.
+func (p *parser) scanTrailingComment() string {
+	tok, lit := p.scan()
+	if tok == tokenWhitespace && !strings.Contains(lit, "\n") {
+		tok, lit = p.scan()
+	}
+	if tok == tokenComment {
+		return lit
+	}
+	p.unscan()
+	return ""
+}
+
 func (p *parser) parseAssignments() ([]*assignment, error) {
 	var assignments []*assignment
 FOR:
@@ -113,36 +209,97 @@ FOR:
 	return assignments, nil
 }
 
+// parsePath parses an identifier followed by zero or more property accesses,
+// up to but not including the following '=', ';', or EOF.
+func (p *parser) parsePath() (string, []interface{}, error) {
+	tok, lit := p.scanIgnoreWhitespaceAndComments()
+	if tok != tokenIdentifier {
+		p.unscan()
+		return "", nil, newErrUnexpected(tok, lit, tokenIdentifier)
+	}
+	properties, _, err := p.parseProperties(false)
+	if err != nil {
+		return "", nil, err
+	}
+	return lit, properties, nil
+}
+
+// parseProperties parses zero or more property accesses, up to but not
+// including the following '=', ';', or EOF. If allowAppend is true then the
+// property accesses may end with `[]`, in which case appending is true.
+func (p *parser) parseProperties(allowAppend bool) (properties []interface{}, appending bool, err error) {
+	p.utf8Path = false
+	for {
+		tok, lit := p.scanIgnoreWhitespaceAndComments()
+		switch {
+		case tok == token('=') || tok == token(';') || tok == tokenEOF:
+			p.unscan()
+			return properties, false, nil
+		case tok == token('[') && allowAppend:
+			if tok, _ := p.scanIgnoreWhitespaceAndComments(); tok == token(']') {
+				if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token('=') {
+					return nil, false, newErrUnexpected(tok, lit, token('='))
+				}
+				p.unscan()
+				return properties, true, nil
+			}
+			p.unscan()
+			property, err := p.parseIndex()
+			if err != nil {
+				return nil, false, err
+			}
+			properties = append(properties, property)
+		case tok == token('.') || tok == token('['):
+			p.unscan()
+			property, err := p.parsePropertyAccess()
+			if err != nil {
+				return nil, false, err
+			}
+			properties = append(properties, property)
+		default:
+			return nil, false, newErrUnexpected(tok, lit, token('='), token('.'), token('['))
+		}
+	}
+}
+
 func (p *parser) parsePropertyAccess() (interface{}, error) {
 	tok, lit := p.scanIgnoreWhitespaceAndComments()
 	switch tok {
 	case token('.'):
 		switch tok, lit := p.scanIgnoreWhitespaceAndComments(); tok {
-		case tokenIdentifier:
+		case tokenIdentifier, tokenFalse, tokenNull, tokenTrue:
+			if !isASCII(lit) {
+				p.utf8Path = true
+			}
 			return lit, nil
 		default:
 			return nil, newErrUnexpected(tok, lit, tokenIdentifier)
 		}
 	case token('['):
-		var property interface{}
-		switch tok, lit := p.scanIgnoreWhitespaceAndComments(); tok {
-		case tokenNumber:
-			property64, _ := strconv.ParseUint(lit, 10, 64)
-			property = int(property64)
-		case tokenString:
-			property = lit
-		default:
-			return nil, newErrUnexpected(tok, lit, tokenNumber, tokenString)
-		}
-		if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token(']') {
-			return nil, newErrUnexpected(tok, lit, token(']'))
-		}
-		return property, nil
+		return p.parseIndex()
 	default:
 		return nil, newErrUnexpected(tok, lit, token('.'), token('['))
 	}
 }
 
+// parseIndex parses the rest of a computed property access after the '['.
+func (p *parser) parseIndex() (interface{}, error) {
+	var property interface{}
+	switch tok, lit := p.scanIgnoreWhitespaceAndComments(); tok {
+	case tokenNumber:
+		property64, _ := strconv.ParseUint(lit, 10, 64)
+		property = int(property64)
+	case tokenString:
+		property = lit
+	default:
+		return nil, newErrUnexpected(tok, lit, tokenNumber, tokenString)
+	}
+	if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token(']') {
+		return nil, newErrUnexpected(tok, lit, token(']'))
+	}
+	return property, nil
+}
+
 func (p *parser) parseValue() (interface{}, error) {
 	tok, lit := p.scanIgnoreWhitespaceAndComments()
 	switch tok {
@@ -157,22 +314,68 @@ func (p *parser) parseValue() (interface{}, error) {
 	case tokenNull:
 		return nil, nil
 	case '[':
-		tok, lit := p.scanIgnoreWhitespaceAndComments()
-		if tok != token(']') {
-			return nil, newErrUnexpected(tok, lit, token(']'))
-		}
-		return []interface{}{}, nil
+		return p.parseArray()
 	case '{':
-		tok, lit := p.scanIgnoreWhitespaceAndComments()
-		if tok != token('}') {
-			return nil, newErrUnexpected(tok, lit, token('}'))
-		}
-		return make(map[string]interface{}), nil
+		return p.parseObject()
 	default:
 		return nil, newErrUnexpected(tok, lit, tokenNumber, tokenString, tokenFalse, tokenTrue, tokenNull, token('['), token('{'))
 	}
 }
 
+// parseArray parses the rest of a JSON array literal after the '['.
+func (p *parser) parseArray() (interface{}, error) {
+	array := []interface{}{}
+	if tok, _ := p.scanIgnoreWhitespaceAndComments(); tok == token(']') {
+		return array, nil
+	}
+	p.unscan()
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		switch tok, lit := p.scanIgnoreWhitespaceAndComments(); tok {
+		case token(','):
+		case token(']'):
+			return array, nil
+		default:
+			return nil, newErrUnexpected(tok, lit, token(','), token(']'))
+		}
+	}
+}
+
+// parseObject parses the rest of a JSON object literal after the '{'.
+func (p *parser) parseObject() (interface{}, error) {
+	object := make(map[string]interface{})
+	if tok, _ := p.scanIgnoreWhitespaceAndComments(); tok == token('}') {
+		return object, nil
+	}
+	p.unscan()
+	for {
+		tok, lit := p.scanIgnoreWhitespaceAndComments()
+		if tok != tokenString {
+			return nil, newErrUnexpected(tok, lit, tokenString)
+		}
+		key := lit
+		if tok, lit := p.scanIgnoreWhitespaceAndComments(); tok != token(':') {
+			return nil, newErrUnexpected(tok, lit, token(':'))
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+		switch tok, lit := p.scanIgnoreWhitespaceAndComments(); tok {
+		case token(','):
+		case token('}'):
+			return object, nil
+		default:
+			return nil, newErrUnexpected(tok, lit, token(','), token('}'))
+		}
+	}
+}
+
 func (p *parser) scan() (token, string) {
 	if p.buf.n > 0 {
 		p.buf.n = 0
@@ -71,6 +71,7 @@ func TestParseAssignment(t *testing.T) {
 			expectedAssignment: &assignment{
 				identifier: "root",
 				value:      json.Number("0"),
+				comment:    "// comment\n",
 			},
 		},
 		{
@@ -78,8 +79,93 @@ func TestParseAssignment(t *testing.T) {
 			expectedAssignment: &assignment{
 				identifier: "root",
 				value:      json.Number("0"),
+				comment:    "/*comment*/",
 			},
 		},
+		{
+			s: "root = [];\n/* comment */\n",
+			expectedAssignment: &assignment{
+				identifier: "root",
+				value:      []interface{}{},
+			},
+		},
+		{
+			s: "delete root.a; /* comment */\n",
+			expectedAssignment: &assignment{
+				op:         assignmentDelete,
+				identifier: "root",
+				properties: []interface{}{"a"},
+				comment:    "/* comment */",
+			},
+		},
+		{
+			s: "delete root.a[0];\n",
+			expectedAssignment: &assignment{
+				op:         assignmentDelete,
+				identifier: "root",
+				properties: []interface{}{"a", 0},
+			},
+		},
+		{
+			s: "delete /*comment*/ root;\n",
+			expectedAssignment: &assignment{
+				op:         assignmentDelete,
+				identifier: "root",
+			},
+		},
+		{
+			s: "root.items[] = 1;\n",
+			expectedAssignment: &assignment{
+				op:         assignmentAppend,
+				identifier: "root",
+				properties: []interface{}{"items"},
+				value:      json.Number("1"),
+			},
+		},
+		{
+			s: "root[0][ ] = {};\n",
+			expectedAssignment: &assignment{
+				op:         assignmentAppend,
+				identifier: "root",
+				properties: []interface{}{0},
+				value:      map[string]interface{}{},
+			},
+		},
+		{
+			s: "root.delete = true;\n",
+			expectedAssignment: &assignment{
+				identifier: "root",
+				properties: []interface{}{"delete"},
+				value:      true,
+			},
+		},
+		{
+			s: "delete = 0;\n",
+			expectedAssignment: &assignment{
+				identifier: "delete",
+				value:      json.Number("0"),
+			},
+		},
+		{
+			s: "delete[0] = 0;\n",
+			expectedAssignment: &assignment{
+				identifier: "delete",
+				properties: []interface{}{0},
+				value:      json.Number("0"),
+			},
+		},
+		{
+			s:         "delete root = 0;\n",
+			expectErr: true,
+		},
+		{
+			s:         "root[].a = 0;\n",
+			expectErr: true,
+		},
+		{
+			s:         "delete root[];\n",
+			expectErr: true,
+		},
 	} {
 		assignment, err := newParser(bytes.NewBufferString(tc.s)).parseAssignment()
 		if tc.expectErr {
@@ -108,6 +194,14 @@ func TestParsePropertyAccess(t *testing.T) {
 		{s: "[a", expectErr: true},
 		{s: "[\"\"]", expectedProperty: ""},
 		{s: "[\"a\"]", expectedProperty: "a"},
+		{s: "['a']", expectedProperty: "a"},
+		{s: ".$ref", expectedProperty: "$ref"},
+		{s: ".données", expectedProperty: "données"},
+		{s: ".true", expectedProperty: "true"},
+		{s: ".null", expectedProperty: "null"},
+		{s: ".\\u0061", expectedProperty: "a"},
+		{s: ".a-b", expectedProperty: "a"},
+		{s: ".-", expectErr: true},
 	} {
 		actualProperty, err := newParser(bytes.NewBufferString(tc.s)).parsePropertyAccess()
 		if tc.expectErr {
@@ -134,6 +228,22 @@ func TestParseValue(t *testing.T) {
 		{s: "\"//comment\n\"", expectedValue: "//comment\n"},
 		{s: "[]", expectedValue: []interface{}{}},
 		{s: "{}", expectedValue: map[string]interface{}{}},
+		{s: "[\"a\", \"b\"]", expectedValue: []interface{}{"a", "b"}},
+		{s: "[1,[2,[]],{}]", expectedValue: []interface{}{json.Number("1"), []interface{}{json.Number("2"), []interface{}{}}, map[string]interface{}{}}},
+		{
+			s: "{ \"a\": 1, /* comment */ \"b\": {\"c\": [true, null]} }",
+			expectedValue: map[string]interface{}{
+				"a": json.Number("1"),
+				"b": map[string]interface{}{"c": []interface{}{true, nil}},
+			},
+		},
+		{s: "{\"a\":1,\"a\":2}", expectedValue: map[string]interface{}{"a": json.Number("2")}},
+		{s: "[1,]", expectErr: true},
+		{s: "[1 2]", expectErr: true},
+		{s: "[1", expectErr: true},
+		{s: "{a: 1}", expectErr: true},
+		{s: "{\"a\" 1}", expectErr: true},
+		{s: "{\"a\": 1,}", expectErr: true},
 	} {
 		actualValue, err := newParser(bytes.NewBufferString(tc.s)).parseValue()
 		if tc.expectErr {
@@ -144,3 +254,54 @@ func TestParseValue(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePath(t *testing.T) {
+	for _, tc := range []struct {
+		s                  string
+		expectedIdentifier string
+		expectedProperties []interface{}
+		expectErr          bool
+	}{
+		{s: "", expectErr: true},
+		{s: "root", expectedIdentifier: "root"},
+		{s: "root.a[0][\"b.c\"]", expectedIdentifier: "root", expectedProperties: []interface{}{"a", 0, "b.c"}},
+		{s: "root.", expectErr: true},
+		{s: "root = 0", expectErr: true},
+		{s: "root.a b", expectErr: true},
+	} {
+		identifier, properties, err := ParsePath(tc.s)
+		if tc.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIdentifier, identifier)
+			assert.Equal(t, tc.expectedProperties, properties)
+			assert.Equal(t, tc.s, FormatPath(identifier, properties))
+		}
+	}
+}
+
+func TestParseAssignmentString(t *testing.T) {
+	for _, tc := range []struct {
+		s             string
+		expectedPath  string
+		expectedValue interface{}
+		expectErr     bool
+	}{
+		{s: "root.a=1", expectedPath: "root.a", expectedValue: json.Number("1")},
+		{s: "root[\"a\"] = \"x\";", expectedPath: "root.a", expectedValue: "x"},
+		{s: "root.a = {}", expectedPath: "root.a", expectedValue: map[string]interface{}{}},
+		{s: "root.a", expectErr: true},
+		{s: "root.a = x", expectErr: true},
+		{s: "root.a = 1; root.b = 2;", expectErr: true},
+	} {
+		path, value, err := ParseAssignment(tc.s)
+		if tc.expectErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPath, path)
+			assert.Equal(t, tc.expectedValue, value)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package flatjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// A PatchConflict is a hunk of a unified diff that could not be applied.
+// Paths are the paths changed by the hunk.
+type PatchConflict struct {
+	Hunk  int
+	Paths []string
+}
+
+func (c *PatchConflict) Error() string {
+	if len(c.Paths) == 0 {
+		return fmt.Sprintf("hunk %d: conflict", c.Hunk)
+	}
+	return fmt.Sprintf("hunk %d: conflict at %s", c.Hunk, strings.Join(c.Paths, ", "))
+}
+
+// ApplyUnifiedDiff applies the unified diff read from r to lines, each ending
+// in a newline, and returns the patched lines. Like patch(1), a hunk is
+// applied at the nearest offset from its original position where it matches
+// and, if it does not match anywhere, up to fuzz lines of leading and trailing
+// context are ignored. Hunks that still do not match are skipped and returned
+// as conflicts.
+func ApplyUnifiedDiff(lines []string, r io.Reader, fuzz int) ([]string, []PatchConflict, error) {
+	hunks, err := parseUnifiedDiff(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := append([]string(nil), lines...)
+	var conflicts []PatchConflict
+	shift, minPos := 0, 0
+	for i, h := range hunks {
+		applied := false
+		for f := 0; f <= fuzz && !applied; f++ {
+			leading, trailing := h.context(f)
+			oldLines, newLines := h.sides(leading, len(h.ops)-trailing)
+			expected := h.oldStart - 1 + leading + shift
+			if h.oldLines == 0 {
+				expected = h.oldStart + shift
+			}
+			pos, ok := findLines(result, oldLines, expected, minPos)
+			if !ok {
+				continue
+			}
+			result = append(result[:pos], append(newLines, result[pos+len(oldLines):]...)...)
+			shift += pos - expected + len(newLines) - len(oldLines)
+			minPos = pos + len(newLines)
+			applied = true
+		}
+		if !applied {
+			conflicts = append(conflicts, PatchConflict{
+				Hunk:  i + 1,
+				Paths: h.paths(),
+			})
+		}
+	}
+	return result, conflicts, nil
+}
+
+// A hunk is a hunk of a unified diff.
+type hunk struct {
+	oldStart int
+	oldLines int
+	newLines int
+	ops      []byte
+	lines    []string
+}
+
+// context returns the numbers of leading and trailing context lines to ignore
+// with fuzz.
+func (h *hunk) context(fuzz int) (int, int) {
+	leading := 0
+	for leading < len(h.ops) && leading < fuzz && h.ops[leading] == ' ' {
+		leading++
+	}
+	trailing := 0
+	for trailing < len(h.ops)-leading && trailing < fuzz && h.ops[len(h.ops)-1-trailing] == ' ' {
+		trailing++
+	}
+	return leading, trailing
+}
+
+// paths returns the paths of the lines removed or added by h.
+func (h *hunk) paths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for i, op := range h.ops {
+		if op == ' ' {
+			continue
+		}
+		identifier, properties, err := parseAssignmentPath(h.lines[i])
+		if err != nil {
+			continue
+		}
+		if path := FormatPath(identifier, properties); !seen[path] {
+			paths = append(paths, path)
+			seen[path] = true
+		}
+	}
+	return paths
+}
+
+// sides returns the old and new lines of h between start and end.
+func (h *hunk) sides(start, end int) ([]string, []string) {
+	var oldLines, newLines []string
+	for i := start; i < end; i++ {
+		if h.ops[i] != '+' {
+			oldLines = append(oldLines, h.lines[i])
+		}
+		if h.ops[i] != '-' {
+			newLines = append(newLines, h.lines[i])
+		}
+	}
+	return oldLines, newLines
+}
+
+// findLines returns the position of needle in lines nearest to expected and
+// not before minPos.
+func findLines(lines, needle []string, expected, minPos int) (int, bool) {
+	maxPos := len(lines) - len(needle)
+	for delta := 0; expected-delta >= minPos || expected+delta <= maxPos; delta++ {
+		for _, pos := range []int{expected - delta, expected + delta} {
+			if pos >= minPos && pos <= maxPos && equalLines(lines[pos:pos+len(needle)], needle) {
+				return pos, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func equalLines(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseUnifiedDiff parses the hunks of the unified diff read from r. Lines
+// outside hunks, like file headers, are ignored.
+func parseUnifiedDiff(r io.Reader) ([]*hunk, error) {
+	var hunks []*hunk
+	br := bufio.NewReader(r)
+	var h *hunk
+	oldRemaining, newRemaining := 0, 0
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		switch {
+		case oldRemaining > 0 || newRemaining > 0:
+			op, text := line[0], line[1:]
+			if line == "\n" {
+				op, text = ' ', "\n"
+			}
+			switch op {
+			case ' ':
+				oldRemaining--
+				newRemaining--
+			case '-':
+				oldRemaining--
+			case '+':
+				newRemaining--
+			case '\\':
+				continue
+			default:
+				return nil, fmt.Errorf("%d: invalid line in hunk", lineNumber)
+			}
+			if oldRemaining < 0 || newRemaining < 0 {
+				return nil, fmt.Errorf("%d: hunk too long", lineNumber)
+			}
+			h.ops = append(h.ops, op)
+			h.lines = append(h.lines, text)
+		case strings.HasPrefix(line, "@@ "):
+			match := hunkHeaderRegexp.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("%d: invalid hunk header", lineNumber)
+			}
+			h = &hunk{
+				oldStart: atoiDefault(match[1], 1),
+				oldLines: atoiDefault(match[2], 1),
+				newLines: atoiDefault(match[4], 1),
+			}
+			oldRemaining, newRemaining = h.oldLines, h.newLines
+			hunks = append(hunks, h)
+		}
+	}
+	if oldRemaining > 0 || newRemaining > 0 {
+		return nil, fmt.Errorf("hunk %d: unexpected end of diff", len(hunks))
+	}
+	return hunks, nil
+}
+
+// atoiDefault returns the integer value of s, or defaultValue if s is empty.
+// s must only contain digits.
+func atoiDefault(s string, defaultValue int) int {
+	if s == "" {
+		return defaultValue
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
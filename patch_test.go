@@ -0,0 +1,172 @@
+package flatjson
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	diff := strings.Join([]string{
+		"--- a.json",
+		"+++ b.json",
+		"@@ -1,4 +1,4 @@ root",
+		" root = {};",
+		" root.a = 1;",
+		"-root.b = 2;",
+		"+root.b = 3;",
+		" root.c = 4;",
+		"@@ -6,3 +6,4 @@ root",
+		" root.e = 6;",
+		" root.f = 7;",
+		"+root.g = 8;",
+		" root.h = 9;",
+		"",
+	}, "\n")
+	for i, tc := range []struct {
+		lines             []string
+		fuzz              int
+		expected          []string
+		expectedConflicts []PatchConflict
+	}{
+		{
+			lines: []string{
+				"root = {};\n",
+				"root.a = 1;\n",
+				"root.b = 2;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.h = 9;\n",
+			},
+			expected: []string{
+				"root = {};\n",
+				"root.a = 1;\n",
+				"root.b = 3;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.g = 8;\n",
+				"root.h = 9;\n",
+			},
+		},
+		{
+			lines: []string{
+				"root = {};\n",
+				"root.a = 1;\n",
+				"root.b = 2;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.d1 = 5;\n",
+				"root.d2 = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.h = 9;\n",
+			},
+			expected: []string{
+				"root = {};\n",
+				"root.a = 1;\n",
+				"root.b = 3;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.d1 = 5;\n",
+				"root.d2 = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.g = 8;\n",
+				"root.h = 9;\n",
+			},
+		},
+		{
+			lines: []string{
+				"root = {};\n",
+				"root.a = 0;\n",
+				"root.b = 2;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.h = 10;\n",
+			},
+			expected: []string{
+				"root = {};\n",
+				"root.a = 0;\n",
+				"root.b = 2;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.h = 10;\n",
+			},
+			expectedConflicts: []PatchConflict{
+				{Hunk: 1, Paths: []string{"root.b"}},
+				{Hunk: 2, Paths: []string{"root.g"}},
+			},
+		},
+		{
+			lines: []string{
+				"root = {};\n",
+				"root.a = 0;\n",
+				"root.b = 2;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.h = 10;\n",
+			},
+			fuzz: 2,
+			expected: []string{
+				"root = {};\n",
+				"root.a = 0;\n",
+				"root.b = 3;\n",
+				"root.c = 4;\n",
+				"root.d = 5;\n",
+				"root.e = 6;\n",
+				"root.f = 7;\n",
+				"root.g = 8;\n",
+				"root.h = 10;\n",
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, actualConflicts, err := ApplyUnifiedDiff(tc.lines, strings.NewReader(diff), tc.fuzz)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectedConflicts, actualConflicts)
+		})
+	}
+}
+
+func TestApplyUnifiedDiffRoundTrip(t *testing.T) {
+	a := SplitLines("root = [];\nroot[0] = 1;\nroot[1] = 2;\n")
+	b := SplitLines("root = [];\nroot[0] = 0;\nroot[1] = 1;\nroot[2] = 2;\n")
+	sb := &strings.Builder{}
+	assert.NoError(t, WriteUnifiedDiff(sb, UnifiedDiff{A: a, B: b, Context: 3}))
+	actual, conflicts, err := ApplyUnifiedDiff(a, strings.NewReader(sb.String()), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, b, actual)
+	assert.Zero(t, conflicts)
+}
+
+func TestParseUnifiedDiffErrors(t *testing.T) {
+	for i, diff := range []string{
+		"@@ -1 +1 @@\n",
+		"@@ -1,2 +1 @@\n x\n y\n",
+		"@@ -1 +1 @@\n?x\n",
+		"@@ x @@\n",
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := parseUnifiedDiff(strings.NewReader(diff))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPatchConflictError(t *testing.T) {
+	assert.EqualError(t, &PatchConflict{Hunk: 2, Paths: []string{"root.a", "root.b"}}, "hunk 2: conflict at root.a, root.b")
+	assert.EqualError(t, &PatchConflict{Hunk: 1}, "hunk 1: conflict")
+}
@@ -0,0 +1,55 @@
+package flatjson
+
+import "strings"
+
+// matchPattern returns whether path matches pattern. In pattern, `**` matches
+// any sequence of characters, `*` matches any sequence of characters other
+// than `.`, `[`, and `]`, and all other characters match themselves. For
+// example, `root.items[*].tags` matches the tags of every element of
+// root.items and `root.**.id` matches every id below root.
+func matchPattern(pattern, path string) bool {
+	// matches[i] is whether the pattern so far matches path[:i]. Each element
+	// of the pattern is matched against all prefixes of path at once, so the
+	// running time is linear in the product of the lengths of pattern and path.
+	matches := make([]bool, len(path)+1)
+	matches[0] = true
+	for len(pattern) > 0 {
+		next := make([]bool, len(path)+1)
+		switch {
+		case strings.HasPrefix(pattern, "**"):
+			matched := false
+			for i := range matches {
+				matched = matched || matches[i]
+				next[i] = matched
+			}
+			pattern = pattern[2:]
+		case pattern[0] == '*':
+			matched := false
+			for i := range matches {
+				matched = matched || matches[i]
+				next[i] = matched
+				if i < len(path) && strings.IndexByte(".[]", path[i]) != -1 {
+					matched = false
+				}
+			}
+			pattern = pattern[1:]
+		default:
+			for i := range len(path) {
+				next[i+1] = matches[i] && path[i] == pattern[0]
+			}
+			pattern = pattern[1:]
+		}
+		matches = next
+	}
+	return matches[len(path)]
+}
+
+// matchAnyPattern returns whether path matches any of patterns.
+func matchAnyPattern(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package flatjson
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestMatchPattern(t *testing.T) {
+	for i, tc := range []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{pattern: "", path: "", expected: true},
+		{pattern: "root", path: "root", expected: true},
+		{pattern: "root", path: "root.a", expected: false},
+		{pattern: "root.a", path: "root", expected: false},
+		{pattern: "root.*", path: "root.a", expected: true},
+		{pattern: "root.*", path: "root.a.b", expected: false},
+		{pattern: "root.*", path: "root[0]", expected: false},
+		{pattern: "root[*]", path: "root[0]", expected: true},
+		{pattern: "root[*]", path: "root[12]", expected: true},
+		{pattern: "root[*]", path: "root[0][1]", expected: false},
+		{pattern: "root.items[*].tags", path: "root.items[3].tags", expected: true},
+		{pattern: "root.items[*].tags", path: "root.items[3].name", expected: false},
+		{pattern: "root.**", path: "root.a[0].b", expected: true},
+		{pattern: "root.**.id", path: "root.a[0].id", expected: true},
+		{pattern: "root.**.id", path: "root.id", expected: false},
+		{pattern: "**.id", path: "root.id", expected: true},
+		{pattern: "**", path: "root", expected: true},
+		{pattern: "root.*Time", path: "root.creationTime", expected: true},
+		{pattern: "**a**a**a**a**a**a**a**a**b", path: strings.Repeat("a", 64), expected: false},
+		{pattern: "*a*a*a*a*a*a*a*a*b", path: strings.Repeat("a", 64), expected: false},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchPattern(tc.pattern, tc.path))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package flatjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// An escaping is a way of escaping characters in string literals.
+type escaping int
+
+const (
+	// escapeJSON escapes like encoding/json, which also escapes <, >, and &
+	// so that the output is safe to embed in HTML.
+	escapeJSON escaping = iota
+	// escapeASCII escapes all non-ASCII characters, like
+	// strconv.QuoteToASCII.
+	escapeASCII
+	// escapeMinimal escapes only what JSON and JavaScript require and writes
+	// other characters as UTF-8.
+	escapeMinimal
+)
+
+// quoteString returns s as a string literal that is valid in both JSON and
+// JavaScript, with characters escaped according to e. Control characters,
+// U+2028, and U+2029 are always escaped, and invalid UTF-8 is replaced with an
+// escaped U+FFFD.
+func quoteString(s string, e escaping) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			sb.WriteString(`\ufffd`)
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\b':
+			sb.WriteString(`\b`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		default:
+			writeEscapedRune(&sb, r, e)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// quoteTemplateLiteral returns s as a JavaScript template literal in which
+// newlines and tabs are written literally. Other characters are escaped as in
+// quoteString.
+func quoteTemplateLiteral(s string, e escaping) string {
+	var sb strings.Builder
+	sb.WriteByte('`')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			sb.WriteString(`\ufffd`)
+		case r == '\\' || r == '`':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '$' && strings.HasPrefix(s[i:], "{"):
+			sb.WriteString(`\$`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\n' || r == '\t':
+			sb.WriteRune(r)
+		default:
+			writeEscapedRune(&sb, r, e)
+		}
+	}
+	sb.WriteByte('`')
+	return sb.String()
+}
+
+// writeEscapedRune writes r to sb, escaping it if it is a control character,
+// U+2028, or U+2029, or if e requires it.
+func writeEscapedRune(sb *strings.Builder, r rune, e escaping) {
+	switch {
+	case r < 0x20 || r == '\u2028' || r == '\u2029':
+		fmt.Fprintf(sb, `\u%04x`, r)
+	case e == escapeJSON && (r == '<' || r == '>' || r == '&'):
+		fmt.Fprintf(sb, `\u%04x`, r)
+	case r < utf8.RuneSelf || e != escapeASCII:
+		sb.WriteRune(r)
+	case r > 0xffff:
+		r1, r2 := utf16.EncodeRune(r)
+		fmt.Fprintf(sb, `\u%04x\u%04x`, r1, r2)
+	default:
+		fmt.Fprintf(sb, `\u%04x`, r)
+	}
+}
+
+// isASCII returns whether s contains only ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// marshalValue returns the compact JSON encoding of value with object keys
+// sorted and strings quoted with quoteString. With escapeJSON it is the same as
+// json.Marshal.
+func marshalValue(value interface{}, e escaping) (string, error) {
+	sb := &strings.Builder{}
+	if err := writeValue(sb, value, e); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writeValue(sb *strings.Builder, value interface{}, e escaping) error {
+	switch value := value.(type) {
+	case string:
+		sb.WriteString(quoteString(value, e))
+	case []interface{}:
+		sb.WriteByte('[')
+		for i, element := range value {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeValue(sb, element, e); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte(']')
+	case map[string]interface{}:
+		properties := make([]string, 0, len(value))
+		for property := range value {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		sb.WriteByte('{')
+		for i, property := range properties {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(quoteString(property, e))
+			sb.WriteByte(':')
+			if err := writeValue(sb, value[property], e); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte('}')
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package flatjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestQuoteString(t *testing.T) {
+	for i, tc := range []struct {
+		s               string
+		expectedJSON    string
+		expectedASCII   string
+		expectedMinimal string
+		expectedScanned string
+	}{
+		{
+			s:               "",
+			expectedJSON:    `""`,
+			expectedASCII:   `""`,
+			expectedMinimal: `""`,
+		},
+		{
+			s:               "a\"\\/<>&",
+			expectedJSON:    `"a\"\\/\u003c\u003e\u0026"`,
+			expectedASCII:   `"a\"\\/<>&"`,
+			expectedMinimal: `"a\"\\/<>&"`,
+		},
+		{
+			s:               "\b\f\n\r\t\x00\x1f\x7f",
+			expectedJSON:    `"\b\f\n\r\t\u0000\u001f` + "\x7f" + `"`,
+			expectedASCII:   `"\b\f\n\r\t\u0000\u001f` + "\x7f" + `"`,
+			expectedMinimal: `"\b\f\n\r\t\u0000\u001f` + "\x7f" + `"`,
+		},
+		{
+			s:               "données €",
+			expectedJSON:    `"données €"`,
+			expectedASCII:   `"donn\u00e9es \u20ac"`,
+			expectedMinimal: `"données €"`,
+		},
+		{
+			s:               "😀",
+			expectedJSON:    `"😀"`,
+			expectedASCII:   `"\ud83d\ude00"`,
+			expectedMinimal: `"😀"`,
+		},
+		{
+			s:               "\u2028\u2029",
+			expectedJSON:    `"\u2028\u2029"`,
+			expectedASCII:   `"\u2028\u2029"`,
+			expectedMinimal: `"\u2028\u2029"`,
+		},
+		{
+			s:               "a\xffb",
+			expectedJSON:    `"a\ufffdb"`,
+			expectedASCII:   `"a\ufffdb"`,
+			expectedMinimal: `"a\ufffdb"`,
+			expectedScanned: "a\ufffdb",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			expectedScanned := tc.s
+			if tc.expectedScanned != "" {
+				expectedScanned = tc.expectedScanned
+			}
+			for e, expected := range map[escaping]string{
+				escapeJSON:    tc.expectedJSON,
+				escapeASCII:   tc.expectedASCII,
+				escapeMinimal: tc.expectedMinimal,
+			} {
+				actual := quoteString(tc.s, e)
+				assert.Equal(t, expected, actual)
+				tok, lit := newScanner(bytes.NewBufferString(actual)).scanString()
+				assert.Equal(t, tokenString, tok)
+				assert.Equal(t, expectedScanned, lit)
+			}
+			if utf8.ValidString(tc.s) {
+				data, err := json.Marshal(tc.s)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedJSON, string(data))
+			}
+		})
+	}
+}
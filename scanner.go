@@ -6,6 +6,9 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 type token int
@@ -60,12 +63,16 @@ func (t token) formatLiteral(lit string) string {
 // implementation is based on
 // https://blog.gopheracademy.com/advent-2014/parsers-lexers/.
 type scanner struct {
-	r *bufio.Reader
+	r io.RuneScanner
 }
 
 func newScanner(r io.Reader) *scanner {
+	runeScanner, ok := r.(io.RuneScanner)
+	if !ok {
+		runeScanner = bufio.NewReader(r)
+	}
 	return &scanner{
-		r: bufio.NewReader(r),
+		r: runeScanner,
 	}
 }
 
@@ -89,16 +96,19 @@ func (s *scanner) scan() (token, string) {
 	case isDigit(ch) || ch == '-':
 		s.unread()
 		return s.scanNumber()
-	case isLetter(ch) || ch == '_':
+	case isIdentifierStart(ch) || ch == '\\':
 		s.unread()
 		return s.scanIdentifier()
 	case isSpace(ch):
 		s.unread()
 		return s.scanWhitespace()
-	case ch == '"':
+	case ch == '"' || ch == '\'':
 		s.unread()
 		return s.scanString()
-	case ch == '.' || ch == ';' || ch == '=' || ch == '[' || ch == ']' || ch == '{' || ch == '}':
+	case ch == '`':
+		s.unread()
+		return s.scanTemplateLiteral()
+	case ch == '.' || ch == ';' || ch == '=' || ch == '[' || ch == ']' || ch == '{' || ch == '}' || ch == ',' || ch == ':':
 		return token(ch), string(ch)
 	case ch == '/':
 		s.unread()
@@ -154,21 +164,31 @@ func (s *scanner) scanComment() (token, string) {
 	}
 }
 
+// scanIdentifier scans an ECMAScript IdentifierName, which may contain
+// \uXXXX and \u{X...} escapes.
 func (s *scanner) scanIdentifier() (token, string) {
 	var sb strings.Builder
-	sb.WriteRune(s.read())
-FOR:
-	for {
+	for first := true; ; first = false {
 		ch := s.read()
-		switch {
-		case ch == eof:
-			break FOR
-		case !isDigit(ch) && !isLetter(ch) && ch != '_':
+		if ch == '\\' {
+			if ch := s.read(); ch != 'u' {
+				return tokenIllegal, string(ch)
+			}
+			r, ok := s.scanUnicodeEscape()
+			if !ok || (first && !isIdentifierStart(r)) || (!first && !isIdentifierPart(r)) {
+				return tokenIllegal, sb.String()
+			}
+			sb.WriteRune(r)
+			continue
+		}
+		if ch == eof {
+			break
+		}
+		if (first && !isIdentifierStart(ch)) || (!first && !isIdentifierPart(ch)) {
 			s.unread()
-			break FOR
-		default:
-			sb.WriteRune(ch)
+			break
 		}
+		sb.WriteRune(ch)
 	}
 	switch s := sb.String(); s {
 	case "false":
@@ -241,55 +261,175 @@ func (s *scanner) scanNumber() (token, string) {
 	return tokenNumber, sb.String()
 }
 
+// scanString scans an ECMAScript string literal delimited by double or single
+// quotes. Unlike ECMAScript, line terminators are allowed in the literal.
 func (s *scanner) scanString() (token, string) {
+	var runes []rune
+	quote := s.read()
+	if quote != '"' && quote != '\'' {
+		return tokenIllegal, string(quote)
+	}
+	for {
+		switch ch := s.read(); ch {
+		case eof:
+			return tokenIllegal, string(runes)
+		case quote:
+			return tokenString, combineSurrogates(runes)
+		case '\\':
+			var ok bool
+			if runes, ok = s.scanEscape(runes); !ok {
+				return tokenIllegal, string(runes)
+			}
+		default:
+			runes = append(runes, ch)
+		}
+	}
+}
+
+// scanEscape scans the rest of an escape sequence in a string or template
+// literal after the backslash and appends its value to runes. Line
+// continuations have no value. Characters without a special meaning, like \q,
+// escape themselves.
+func (s *scanner) scanEscape(runes []rune) ([]rune, bool) {
+	switch ch := s.read(); ch {
+	case eof:
+		return runes, false
+	case 'b':
+		return append(runes, '\b'), true
+	case 'f':
+		return append(runes, '\f'), true
+	case 'n':
+		return append(runes, '\n'), true
+	case 'r':
+		return append(runes, '\r'), true
+	case 't':
+		return append(runes, '\t'), true
+	case 'v':
+		return append(runes, '\v'), true
+	case '0':
+		if isDigit(s.read()) {
+			return runes, false
+		}
+		s.unread()
+		return append(runes, 0), true
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return runes, false
+	case 'x':
+		r, ok := s.scanHexEscape(2)
+		return append(runes, r), ok
+	case 'u':
+		r, ok := s.scanUnicodeEscape()
+		return append(runes, r), ok
+	case '\r':
+		if s.read() != '\n' {
+			s.unread()
+		}
+		return runes, true
+	case '\n', '\u2028', '\u2029':
+		return runes, true
+	default:
+		return append(runes, ch), true
+	}
+}
+
+// scanUnicodeEscape scans the rest of a \uXXXX or \u{X...} escape after the u
+// and returns its value.
+func (s *scanner) scanUnicodeEscape() (rune, bool) {
+	if s.read() != '{' {
+		s.unread()
+		return s.scanHexEscape(4)
+	}
+	var r rune
+	for n := 0; ; n++ {
+		ch := s.read()
+		switch {
+		case ch == '}':
+			return r, n > 0
+		case isDigit(ch):
+			r = r<<4 | (ch - '0')
+		case 'A' <= ch && ch <= 'F':
+			r = r<<4 | (ch - 'A' + 0xa)
+		case 'a' <= ch && ch <= 'f':
+			r = r<<4 | (ch - 'a' + 0xa)
+		default:
+			return 0, false
+		}
+		if r > unicode.MaxRune {
+			return 0, false
+		}
+	}
+}
+
+// combineSurrogates returns runes as a string, combining UTF-16 surrogate
+// pairs from \uXXXX escapes. Unpaired surrogates are replaced with U+FFFD.
+func combineSurrogates(runes []rune) string {
 	var sb strings.Builder
-	if ch := s.read(); ch != '"' {
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if utf16.IsSurrogate(r) && i+1 < len(runes) {
+			if combined := utf16.DecodeRune(r, runes[i+1]); combined != unicode.ReplacementChar {
+				r = combined
+				i++
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// scanTemplateLiteral scans a JavaScript template literal without
+// substitutions, like `a\nb`, which may span several lines. As in JavaScript,
+// line terminators in the literal are normalized to \n.
+func (s *scanner) scanTemplateLiteral() (token, string) {
+	var runes []rune
+	if ch := s.read(); ch != '`' {
 		return tokenIllegal, string(ch)
 	}
-FOR:
 	for {
+		switch ch := s.read(); ch {
+		case eof:
+			return tokenIllegal, string(runes)
+		case '`':
+			return tokenString, combineSurrogates(runes)
+		case '$':
+			if ch := s.read(); ch == '{' {
+				return tokenIllegal, "${"
+			}
+			s.unread()
+			runes = append(runes, ch)
+		case '\r':
+			if ch := s.read(); ch != '\n' {
+				s.unread()
+			}
+			runes = append(runes, '\n')
+		case '\\':
+			var ok bool
+			if runes, ok = s.scanEscape(runes); !ok {
+				return tokenIllegal, string(runes)
+			}
+		default:
+			runes = append(runes, ch)
+		}
+	}
+}
+
+// scanHexEscape scans n hexadecimal digits and returns their value.
+func (s *scanner) scanHexEscape(n int) (rune, bool) {
+	var r rune
+	for range n {
 		ch := s.read()
 		switch {
-		case ch == '"':
-			break FOR
-		case ch == '\\':
-			switch ch := s.read(); ch {
-			case '"', '\\', '/':
-				sb.WriteRune(ch)
-			case 'b':
-				sb.WriteRune('\b')
-			case 'f':
-				sb.WriteRune('\f')
-			case 'n':
-				sb.WriteRune('\n')
-			case 'r':
-				sb.WriteRune('\r')
-			case 't':
-				sb.WriteRune('\t')
-			case 'u':
-				var r rune
-				for range 4 {
-					ch := s.read()
-					switch {
-					case isDigit(ch):
-						r = (r << 4) | (ch - '0')
-					case 'A' <= ch && ch <= 'F':
-						r = (r << 4) | (ch - 'A' + 0xa)
-					case 'a' <= ch && ch <= 'f':
-						r = (r << 4) | (ch - 'f' + 0xa)
-					default:
-						return tokenIllegal, string(ch)
-					}
-				}
-				sb.WriteRune(r)
-			default:
-				return tokenIllegal, string(ch)
-			}
+		case isDigit(ch):
+			r = r<<4 | (ch - '0')
+		case 'A' <= ch && ch <= 'F':
+			r = r<<4 | (ch - 'A' + 0xa)
+		case 'a' <= ch && ch <= 'f':
+			r = r<<4 | (ch - 'a' + 0xa)
 		default:
-			sb.WriteRune(ch)
+			return 0, false
 		}
 	}
-	return tokenString, sb.String()
+	return r, true
 }
 
 func (s *scanner) scanWhitespace() (token, string) {
@@ -315,6 +455,45 @@ func (s *scanner) unread() {
 	_ = s.r.UnreadRune()
 }
 
-func isDigit(r rune) bool  { return '0' <= r && r <= '9' }
-func isLetter(r rune) bool { return ('A' <= r && r <= 'Z') || ('a' <= r && r <= 'z') }
-func isSpace(r rune) bool  { return r == '\t' || r == '\n' || r == '\r' || r == ' ' }
+func isDigit(r rune) bool { return '0' <= r && r <= '9' }
+func isSpace(r rune) bool { return r == '\t' || r == '\n' || r == '\r' || r == ' ' }
+
+// isIdentifierStart returns whether r can start an ECMAScript IdentifierName.
+func isIdentifierStart(r rune) bool {
+	switch {
+	case r == '$' || r == '_':
+		return true
+	case r < utf8.RuneSelf:
+		return ('A' <= r && r <= 'Z') || ('a' <= r && r <= 'z')
+	default:
+		return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+			!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+	}
+}
+
+// isIdentifierPart returns whether r can continue an ECMAScript
+// IdentifierName.
+func isIdentifierPart(r rune) bool {
+	switch {
+	case isIdentifierStart(r) || isDigit(r):
+		return true
+	case r < utf8.RuneSelf:
+		return false
+	case r == '\u200c' || r == '\u200d':
+		return true
+	default:
+		return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+			!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+	}
+}
+
+// isIdentifierName returns whether s is an ECMAScript IdentifierName without
+// escapes.
+func isIdentifierName(s string) bool {
+	for i, r := range s {
+		if (i == 0 && !isIdentifierStart(r)) || (i > 0 && !isIdentifierPart(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -67,3 +67,121 @@ func TestScanNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestScanTemplateLiteral(t *testing.T) {
+	for i, tc := range []struct {
+		s           string
+		expectedLit string
+		expectErr   bool
+	}{
+		{s: "``", expectedLit: ""},
+		{s: "`a\nb`", expectedLit: "a\nb"},
+		{s: "`a\r\nb\rc`", expectedLit: "a\nb\nc"},
+		{s: "`\\r\\n\\t\\\\\\``", expectedLit: "\r\n\t\\`"},
+		{s: "`$a \\${b} $`", expectedLit: "$a ${b} $"},
+		{s: "`a\\\nb`", expectedLit: "ab"},
+		{s: "`\\u00e9\\x41\\u0001`", expectedLit: "\u00e9A\u0001"},
+		{s: "`\\ud83d\\ude00`", expectedLit: "😀"},
+		{s: "`", expectErr: true},
+		{s: "`${a}`", expectErr: true},
+		{s: "`\\u00g0`", expectErr: true},
+		{s: "`\\q`", expectedLit: "q"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tok, lit := newScanner(bytes.NewBufferString(tc.s)).scanTemplateLiteral()
+			if tc.expectErr {
+				assert.Equal(t, tokenIllegal, tok)
+			} else {
+				assert.Equal(t, tokenString, tok)
+				assert.Equal(t, tc.expectedLit, lit)
+			}
+		})
+	}
+}
+
+func TestScanString(t *testing.T) {
+	for i, tc := range []struct {
+		s           string
+		expectedLit string
+		expectErr   bool
+	}{
+		{s: `""`, expectedLit: ""},
+		{s: `"\u00e9\u00E9"`, expectedLit: "éé"},
+		{s: `"\ud83d\ude00"`, expectedLit: "😀"},
+		{s: `"\ud83dx"`, expectedLit: "\ufffdx"},
+		{s: `"\ude00\ud83d"`, expectedLit: "\ufffd\ufffd"},
+		{s: `"\u00g0"`, expectErr: true},
+		{s: `''`, expectedLit: ""},
+		{s: `'a"b\'c'`, expectedLit: `a"b'c`},
+		{s: `"a'b\"c"`, expectedLit: `a'b"c`},
+		{s: `"\b\f\n\r\t\v\0\\\/"`, expectedLit: "\b\f\n\r\t\v\x00\\/"},
+		{s: `"\x41\x7a\xE9"`, expectedLit: "Azé"},
+		{s: `"\u{41}\u{1F600}\u{0000000e9}"`, expectedLit: "A😀é"},
+		{s: `"\u{d83d}\u{de00}"`, expectedLit: "😀"},
+		{s: "\"\\a\\c\\$\\`\"", expectedLit: "ac$`"},
+		{s: "\"a\\\nb\\\r\nc\\\rd\\\u2028e\"", expectedLit: "abcde"},
+		{s: "\"a\nb\"", expectedLit: "a\nb"},
+		{s: "\"\u2028\"", expectedLit: "\u2028"},
+		{s: `"\0a"`, expectedLit: "\x00a"},
+		{s: `"\00"`, expectErr: true},
+		{s: `"\1"`, expectErr: true},
+		{s: `"\8"`, expectErr: true},
+		{s: `"\x4"`, expectErr: true},
+		{s: `"\u{}"`, expectErr: true},
+		{s: `"\u{110000}"`, expectErr: true},
+		{s: `"\u{41"`, expectErr: true},
+		{s: `"abc`, expectErr: true},
+		{s: `"abc'`, expectErr: true},
+		{s: `"\`, expectErr: true},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tok, lit := newScanner(bytes.NewBufferString(tc.s)).scanString()
+			if tc.expectErr {
+				assert.Equal(t, tokenIllegal, tok)
+			} else {
+				assert.Equal(t, tokenString, tok)
+				assert.Equal(t, tc.expectedLit, lit)
+			}
+		})
+	}
+}
+
+func TestScanIdentifier(t *testing.T) {
+	for i, tc := range []struct {
+		s           string
+		expectedTok token
+		expectedLit string
+	}{
+		{s: "a", expectedTok: tokenIdentifier, expectedLit: "a"},
+		{s: "aB0_", expectedTok: tokenIdentifier, expectedLit: "aB0_"},
+		{s: "_a", expectedTok: tokenIdentifier, expectedLit: "_a"},
+		{s: "$ref", expectedTok: tokenIdentifier, expectedLit: "$ref"},
+		{s: "a$b$", expectedTok: tokenIdentifier, expectedLit: "a$b$"},
+		{s: "données", expectedTok: tokenIdentifier, expectedLit: "données"},
+		{s: "π2", expectedTok: tokenIdentifier, expectedLit: "π2"},
+		{s: "日本語", expectedTok: tokenIdentifier, expectedLit: "日本語"},
+		{s: "Ⅻ", expectedTok: tokenIdentifier, expectedLit: "Ⅻ"},
+		{s: "a\u0301", expectedTok: tokenIdentifier, expectedLit: "a\u0301"},
+		{s: "a\u200db", expectedTok: tokenIdentifier, expectedLit: "a\u200db"},
+		{s: "a.b", expectedTok: tokenIdentifier, expectedLit: "a"},
+		{s: "a-b", expectedTok: tokenIdentifier, expectedLit: "a"},
+		{s: "a\u00a0b", expectedTok: tokenIdentifier, expectedLit: "a"},
+		{s: "a€", expectedTok: tokenIdentifier, expectedLit: "a"},
+		{s: `\u0061b`, expectedTok: tokenIdentifier, expectedLit: "ab"},
+		{s: `a\u{62}`, expectedTok: tokenIdentifier, expectedLit: "ab"},
+		{s: `\u00e9`, expectedTok: tokenIdentifier, expectedLit: "é"},
+		{s: "true", expectedTok: tokenTrue, expectedLit: "true"},
+		{s: "false", expectedTok: tokenFalse, expectedLit: "false"},
+		{s: "null", expectedTok: tokenNull, expectedLit: "null"},
+		{s: "nullable", expectedTok: tokenIdentifier, expectedLit: "nullable"},
+		{s: `\u0030`, expectedTok: tokenIllegal, expectedLit: ""},
+		{s: `a\u002e`, expectedTok: tokenIllegal, expectedLit: "a"},
+		{s: `a\x62`, expectedTok: tokenIllegal, expectedLit: "x"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tok, lit := newScanner(bytes.NewBufferString(tc.s)).scan()
+			assert.Equal(t, tc.expectedTok, tok)
+			assert.Equal(t, tc.expectedLit, lit)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package flatjson
+
+import "sort"
+
+// A PathStat counts the changes to the leaves below a path.
+type PathStat struct {
+	Path    string
+	Added   int
+	Removed int
+	Changed int
+	Moved   int
+}
+
+// StatChanges groups changes by the ancestors of their paths at depth and
+// counts the changes in each group. The depth of a path is its number of
+// property accesses, so at depth 1 changes are grouped by top-level subtree. If
+// depth is zero or negative then changes are not grouped. Changes to
+// containers whose contents were also added or removed are not counted, so
+// only changes to leaves are counted. Groups are returned in the order of
+// their first change, which is path order if changes are in path order.
+func StatChanges(changes []Change, depth int) ([]PathStat, error) {
+	var stats []PathStat
+	indexes := make(map[string]int)
+	for i, change := range changes {
+		if i+1 < len(changes) && isContainer(change) && isDescendant(changes[i+1].Path, change.Path) {
+			continue
+		}
+		identifier, properties, err := ParsePath(change.Path)
+		if err != nil {
+			return nil, err
+		}
+		if depth > 0 && len(properties) > depth {
+			properties = properties[:depth]
+		}
+		path := formatPathLike(change.Path, identifier, properties)
+		index, ok := indexes[path]
+		if !ok {
+			index = len(stats)
+			indexes[path] = index
+			stats = append(stats, PathStat{Path: path})
+		}
+		switch change.Op {
+		case ChangeAdd:
+			stats[index].Added++
+		case ChangeRemove:
+			stats[index].Removed++
+		case ChangeReplace:
+			stats[index].Changed++
+		case ChangeMove:
+			stats[index].Moved++
+		}
+	}
+	return stats, nil
+}
+
+// SortChanges sorts changes by path in the order of DiffFlat, so that moves
+// can be merged with the changes returned by DiffFlat. Changes to the same path
+// keep their order.
+func SortChanges(changes []Change) error {
+	type keyedChange struct {
+		key    pathKey
+		change Change
+	}
+	keyedChanges := make([]keyedChange, 0, len(changes))
+	for _, change := range changes {
+		identifier, properties, err := ParsePath(change.Path)
+		if err != nil {
+			return err
+		}
+		keyedChanges = append(keyedChanges, keyedChange{
+			key: pathKey{
+				identifier: identifier,
+				properties: properties,
+			},
+			change: change,
+		})
+	}
+	sort.SliceStable(keyedChanges, func(i, j int) bool {
+		return comparePathKeys(&keyedChanges[i].key, &keyedChanges[j].key) < 0
+	})
+	for i, keyedChange := range keyedChanges {
+		changes[i] = keyedChange.change
+	}
+	return nil
+}
+
+// AncestorPath returns the ancestor of path at depth, in the same notation as
+// path. The depth of a path is its number of property accesses. If path has
+// depth or fewer property accesses then path itself is returned.
+func AncestorPath(path string, depth int) (string, error) {
+	identifier, properties, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+	if len(properties) > depth {
+		properties = properties[:depth]
+	}
+	return formatPathLike(path, identifier, properties), nil
+}
+
+// isContainer returns whether change adds or removes an object or array.
+func isContainer(change Change) bool {
+	var value interface{}
+	switch change.Op {
+	case ChangeAdd:
+		value = change.New
+	case ChangeRemove:
+		value = change.Old
+	default:
+		return false
+	}
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDescendant returns whether path is a descendant of ancestor.
+func isDescendant(path, ancestor string) bool {
+	return len(path) > len(ancestor) && path[:len(ancestor)] == ancestor && (path[len(ancestor)] == '.' || path[len(ancestor)] == '[')
+}
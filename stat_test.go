@@ -0,0 +1,114 @@
+package flatjson
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestStatChanges(t *testing.T) {
+	changes := []Change{
+		{Op: ChangeAdd, Path: "root.a.b", New: true},
+		{Op: ChangeRemove, Path: "root.a.c", Old: map[string]interface{}{}},
+		{Op: ChangeRemove, Path: "root.a.c.d", Old: 1.0},
+		{Op: ChangeRemove, Path: "root.a.c.e", Old: 2.0},
+		{Op: ChangeAdd, Path: "root.a.f", New: []interface{}{}},
+		{Op: ChangeReplace, Path: "root.b[0]", Old: 1.0, New: 2.0},
+		{Op: ChangeMove, Path: "root.b[1]", From: "root.b[2]"},
+		{Op: ChangeReplace, Path: "root.c", Old: map[string]interface{}{}, New: 1.0},
+	}
+	for i, tc := range []struct {
+		depth    int
+		expected []PathStat
+	}{
+		{
+			depth: 0,
+			expected: []PathStat{
+				{Path: "root.a.b", Added: 1},
+				{Path: "root.a.c.d", Removed: 1},
+				{Path: "root.a.c.e", Removed: 1},
+				{Path: "root.a.f", Added: 1},
+				{Path: "root.b[0]", Changed: 1},
+				{Path: "root.b[1]", Moved: 1},
+				{Path: "root.c", Changed: 1},
+			},
+		},
+		{
+			depth: 1,
+			expected: []PathStat{
+				{Path: "root.a", Added: 2, Removed: 2},
+				{Path: "root.b", Changed: 1, Moved: 1},
+				{Path: "root.c", Changed: 1},
+			},
+		},
+		{
+			depth: 2,
+			expected: []PathStat{
+				{Path: "root.a.b", Added: 1},
+				{Path: "root.a.c", Removed: 2},
+				{Path: "root.a.f", Added: 1},
+				{Path: "root.b[0]", Changed: 1},
+				{Path: "root.b[1]", Moved: 1},
+				{Path: "root.c", Changed: 1},
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := StatChanges(changes, tc.depth)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSortChanges(t *testing.T) {
+	changes := []Change{
+		{Op: ChangeAdd, Path: "root.b"},
+		{Op: ChangeReplace, Path: "root.a[10]"},
+		{Op: ChangeRemove, Path: "root.c"},
+		{Op: ChangeMove, Path: "root.a[2]", From: "root.a[10]"},
+		{Op: ChangeMove, Path: "root.c", From: "root.d"},
+		{Op: ChangeAdd, Path: `root["données"]`},
+	}
+	assert.NoError(t, SortChanges(changes))
+	assert.Equal(t, []Change{
+		{Op: ChangeMove, Path: "root.a[2]", From: "root.a[10]"},
+		{Op: ChangeReplace, Path: "root.a[10]"},
+		{Op: ChangeAdd, Path: "root.b"},
+		{Op: ChangeRemove, Path: "root.c"},
+		{Op: ChangeMove, Path: "root.c", From: "root.d"},
+		{Op: ChangeAdd, Path: `root["données"]`},
+	}, changes)
+	assert.Error(t, SortChanges([]Change{{Path: "root."}}))
+}
+
+func TestAncestorPath(t *testing.T) {
+	for i, tc := range []struct {
+		path     string
+		depth    int
+		expected string
+	}{
+		{path: "root", depth: 1, expected: "root"},
+		{path: "root.a.b", depth: 0, expected: "root"},
+		{path: "root.a.b", depth: 1, expected: "root.a"},
+		{path: "root.a.b", depth: 3, expected: "root.a.b"},
+		{path: "root.a[0].b", depth: 2, expected: "root.a[0]"},
+		{path: `root["données"].t[0]`, depth: 1, expected: `root["données"]`},
+		{path: "root.données.t[0]", depth: 1, expected: "root.données"},
+		{path: "root.données.日本語", depth: 2, expected: "root.données.日本語"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			actual, err := AncestorPath(tc.path, tc.depth)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestIsDescendant(t *testing.T) {
+	assert.True(t, isDescendant("root.a.b", "root.a"))
+	assert.True(t, isDescendant("root.a[0]", "root.a"))
+	assert.False(t, isDescendant("root.ab", "root.a"))
+	assert.False(t, isDescendant("root.a", "root.a"))
+}
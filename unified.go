@@ -0,0 +1,437 @@
+package flatjson
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ANSI escape sequences, matching git's default colors.
+const (
+	colorMeta  = "\x1b[1m"
+	colorFrag  = "\x1b[36m"
+	colorOld   = "\x1b[31m"
+	colorNew   = "\x1b[32m"
+	colorReset = "\x1b[m"
+)
+
+const (
+	defaultWidth  = 130
+	sideBySideGap = 3
+)
+
+// A UnifiedDiff is a unified diff between two flattened JSON values. A and B
+// are lines, each ending in a newline.
+//
+// If Color is set then the diff is colored with ANSI escape sequences like
+// git. If SideBySide is set then old and new lines are written next to each
+// other in two columns, aligned by path, within a total of Width terminal
+// cells, in which wide East Asian characters and emoji take two cells.
+// If WordDiff is set then changed lines with the same path are written once,
+// with the changed words marked like git's --word-diff option.
+type UnifiedDiff struct {
+	A          []string
+	B          []string
+	FromFile   string
+	ToFile     string
+	Context    int
+	Color      bool
+	SideBySide bool
+	Width      int
+	WordDiff   bool
+}
+
+// SplitLines splits s after each newline and appends a newline to the final
+// line, like github.com/pmezard/go-difflib/difflib.SplitLines, so that hunk
+// line counts match those of earlier versions.
+func SplitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// WriteUnifiedDiff writes diff to w. Like the function names that git writes
+// in hunk headers, each hunk header is followed by the nearest common ancestor
+// path of the lines changed in the hunk.
+func WriteUnifiedDiff(w io.Writer, diff UnifiedDiff) error {
+	groups := groupOpCodes(DiffLines(diff.A, diff.B), diff.Context)
+	if len(groups) == 0 {
+		return nil
+	}
+	header := diff.colorize(colorMeta, "--- "+diff.FromFile+"\n") + diff.colorize(colorMeta, "+++ "+diff.ToFile+"\n")
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	assignmentsA, assignmentsB := lineAssignments(diff.A), lineAssignments(diff.B)
+	for _, group := range groups {
+		sb := &strings.Builder{}
+		first, last := group[0], group[len(group)-1]
+		sb.WriteString(diff.colorize(colorFrag, "@@ -"+formatRangeUnified(first.I1, last.I2)+" +"+formatRangeUnified(first.J1, last.J2)+" @@"))
+		if path := hunkPath(assignmentsA, assignmentsB, group); path != "" {
+			sb.WriteString(" " + path)
+		}
+		sb.WriteString("\n")
+		switch {
+		case diff.SideBySide:
+			diff.writeSideBySideHunk(sb, group)
+		case diff.WordDiff:
+			diff.writeWordDiffHunk(sb, group)
+		default:
+			diff.writeUnifiedHunk(sb, group)
+		}
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// colorize returns line colored with color, if colors are enabled.
+func (d *UnifiedDiff) colorize(color, line string) string {
+	if !d.Color || color == "" || line == "" {
+		return line
+	}
+	body, newline := strings.CutSuffix(line, "\n")
+	if newline {
+		return color + body + colorReset + "\n"
+	}
+	return color + body + colorReset
+}
+
+// column returns line without its newline, truncated or padded to width
+// terminal cells.
+func column(line string, width int, pad bool) string {
+	line = strings.TrimSuffix(line, "\n")
+	used := 0
+	for i, r := range line {
+		w := runeWidth(r)
+		if used+w > width {
+			line = line[:i]
+			break
+		}
+		used += w
+	}
+	if pad {
+		return line + strings.Repeat(" ", width-used)
+	}
+	return line
+}
+
+// wideRanges are the ranges of runes that take two terminal cells: the wide
+// and fullwidth East Asian characters and emoji.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232a, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x25fd, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x26aa, Hi: 0x26ab, Stride: 1},
+		{Lo: 0x26bd, Hi: 0x26be, Stride: 1},
+		{Lo: 0x26c4, Hi: 0x26c5, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe10, Hi: 0xfe19, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe6f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16fe0, Hi: 0x18cff, Stride: 1},
+		{Lo: 0x1b000, Hi: 0x1b2ff, Stride: 1},
+		{Lo: 0x1f004, Hi: 0x1f004, Stride: 1},
+		{Lo: 0x1f0cf, Hi: 0x1f0cf, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f200, Hi: 0x1f251, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f7e0, Hi: 0x1f7eb, Stride: 1},
+		{Lo: 0x1f90c, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x1fa70, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2fffd, Stride: 1},
+		{Lo: 0x30000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// runeWidth returns the number of terminal cells that r takes: zero for
+// combining marks and format characters, two for wide characters, and one
+// otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRanges, r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (d *UnifiedDiff) writeSideBySideHunk(sb *strings.Builder, group []OpCode) {
+	width := d.Width
+	if width <= 0 {
+		width = defaultWidth
+	}
+	columnWidth := max((width-sideBySideGap)/2, 1)
+	for _, opCode := range alignOpCodes(d.A, d.B, group) {
+		var left, right, marker, leftColor, rightColor string
+		switch opCode.Tag {
+		case 'e':
+			left, right, marker = d.A[opCode.I1], d.B[opCode.J1], " "
+		case 'r':
+			left, right, marker, leftColor, rightColor = d.A[opCode.I1], d.B[opCode.J1], "|", colorOld, colorNew
+		case 'd':
+			left, marker, leftColor = d.A[opCode.I1], "<", colorOld
+		case 'i':
+			right, marker, rightColor = d.B[opCode.J1], ">", colorNew
+		}
+		row := d.colorize(leftColor, column(left, columnWidth, true)) + " " + marker
+		if right != "" {
+			row += " " + d.colorize(rightColor, column(right, columnWidth, false))
+		}
+		sb.WriteString(row + "\n")
+	}
+}
+
+func (d *UnifiedDiff) writeUnifiedHunk(sb *strings.Builder, group []OpCode) {
+	for _, opCode := range group {
+		if opCode.Tag == 'e' {
+			d.writeLines(sb, "", " ", d.A[opCode.I1:opCode.I2])
+			continue
+		}
+		if opCode.Tag == 'r' || opCode.Tag == 'd' {
+			d.writeLines(sb, colorOld, "-", d.A[opCode.I1:opCode.I2])
+		}
+		if opCode.Tag == 'r' || opCode.Tag == 'i' {
+			d.writeLines(sb, colorNew, "+", d.B[opCode.J1:opCode.J2])
+		}
+	}
+}
+
+func (d *UnifiedDiff) writeWordDiffHunk(sb *strings.Builder, group []OpCode) {
+	for _, opCode := range alignOpCodes(d.A, d.B, group) {
+		switch opCode.Tag {
+		case 'e':
+			sb.WriteString(d.A[opCode.I1])
+		case 'r':
+			sb.WriteString(d.wordDiff(d.A[opCode.I1], d.B[opCode.J1]))
+		case 'd':
+			sb.WriteString(d.markWords(false, strings.TrimSuffix(d.A[opCode.I1], "\n")) + "\n")
+		case 'i':
+			sb.WriteString(d.markWords(true, strings.TrimSuffix(d.B[opCode.J1], "\n")) + "\n")
+		}
+	}
+}
+
+func (d *UnifiedDiff) writeLines(sb *strings.Builder, color, prefix string, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(d.colorize(color, prefix+line))
+	}
+}
+
+// markWords returns words marked as added or removed.
+func (d *UnifiedDiff) markWords(added bool, words string) string {
+	switch {
+	case words == "":
+		return ""
+	case d.Color && added:
+		return colorNew + words + colorReset
+	case d.Color:
+		return colorOld + words + colorReset
+	case added:
+		return "{+" + words + "+}"
+	default:
+		return "[-" + words + "-]"
+	}
+}
+
+// wordDiff returns a single line showing the words changed between a and b.
+func (d *UnifiedDiff) wordDiff(a, b string) string {
+	wordsA := splitWords(strings.TrimSuffix(a, "\n"))
+	wordsB := splitWords(strings.TrimSuffix(b, "\n"))
+	sb := &strings.Builder{}
+	for _, opCode := range myersDiffLines(wordsA, wordsB) {
+		removed := strings.Join(wordsA[opCode.I1:opCode.I2], "")
+		if opCode.Tag == 'e' {
+			sb.WriteString(removed)
+			continue
+		}
+		sb.WriteString(d.markWords(false, removed))
+		sb.WriteString(d.markWords(true, strings.Join(wordsB[opCode.J1:opCode.J2], "")))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// alignOpCodes splits opCodes into OpCodes that each cover a single line of a,
+// b, or both. Within changes, lines of a and b with the same path are paired.
+func alignOpCodes(a, b []string, opCodes []OpCode) []OpCode {
+	var result []OpCode
+	for _, opCode := range opCodes {
+		if opCode.Tag == 'e' {
+			for k := range opCode.I2 - opCode.I1 {
+				result = append(result, OpCode{Tag: 'e', I1: opCode.I1 + k, I2: opCode.I1 + k + 1, J1: opCode.J1 + k, J2: opCode.J1 + k + 1})
+			}
+			continue
+		}
+		i, j := opCode.I1, opCode.J1
+		keysA, okA := sortedPathKeys(a[opCode.I1:opCode.I2])
+		keysB, okB := sortedPathKeys(b[opCode.J1:opCode.J2])
+		for i < opCode.I2 || j < opCode.J2 {
+			c := 0
+			switch {
+			case j == opCode.J2:
+				c = -1
+			case i == opCode.I2:
+				c = 1
+			case okA && okB:
+				c = comparePathKeys(&keysA[i-opCode.I1], &keysB[j-opCode.J1])
+			}
+			switch c {
+			case -1:
+				result = append(result, OpCode{Tag: 'd', I1: i, I2: i + 1, J1: j, J2: j})
+				i++
+			case 1:
+				result = append(result, OpCode{Tag: 'i', I1: i, I2: i, J1: j, J2: j + 1})
+				j++
+			default:
+				result = append(result, OpCode{Tag: 'r', I1: i, I2: i + 1, J1: j, J2: j + 1})
+				i++
+				j++
+			}
+		}
+	}
+	return result
+}
+
+// splitWords splits s into runs of letters and digits and individual other
+// characters.
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	inWord := false
+	for i, r := range s {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		if i > start && (!isWordRune || !inWord) {
+			words = append(words, s[start:i])
+			start = i
+		}
+		inWord = isWordRune
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// hunkPath returns the nearest common ancestor path of the assignments that
+// the lines changed by opCodes belong to, or the empty string if there is
+// none. a and b are the assignments that the lines of A and B belong to.
+func hunkPath(a, b []lineAssignment, opCodes []OpCode) string {
+	var keys []pathKey
+	var like string
+	add := func(assignment lineAssignment) {
+		if assignment.line == "" {
+			return
+		}
+		keys = append(keys, assignment.key)
+		if like == "" {
+			like = assignment.line
+		}
+	}
+	for _, opCode := range opCodes {
+		if opCode.Tag == 'e' {
+			continue
+		}
+		for i := opCode.I1; i < opCode.I2; i++ {
+			add(a[i])
+		}
+		for j := opCode.J1; j < opCode.J2; j++ {
+			add(b[j])
+		}
+	}
+	return commonAncestorPath(keys, like)
+}
+
+// A lineAssignment is the assignment that a line belongs to.
+type lineAssignment struct {
+	key  pathKey
+	line string // the line of the assignment, empty if there is none
+}
+
+// lineAssignments returns the assignment that each of lines belongs to, in a
+// single pass over lines. Lines that are not assignments, like the
+// continuation lines of template literals, belong to the nearest preceding
+// assignment.
+func lineAssignments(lines []string) []lineAssignment {
+	assignments := make([]lineAssignment, len(lines))
+	var assignment lineAssignment
+	for i, line := range lines {
+		if key, ok := parseLinePathKey(line); ok {
+			assignment = lineAssignment{key: key, line: line}
+		} else if identifier, properties, err := parseAssignmentPath(line); err == nil {
+			assignment = lineAssignment{
+				key: pathKey{
+					identifier: identifier,
+					properties: properties,
+				},
+				line: line,
+			}
+		}
+		assignments[i] = assignment
+	}
+	return assignments
+}
+
+// commonAncestorPath returns the nearest common ancestor path of keys, in the
+// same notation as like, the line of the assignment of one of keys, or the
+// empty string if there is none.
+func commonAncestorPath(keys []pathKey, like string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	identifier, ancestor := keys[0].identifier, keys[0].properties
+	for _, key := range keys[1:] {
+		if key.identifier != identifier {
+			return ""
+		}
+		n := 0
+		for n < len(ancestor) && n < len(key.properties) && ancestor[n] == key.properties[n] {
+			n++
+		}
+		ancestor = ancestor[:n]
+	}
+	return formatPathLike(like, identifier, ancestor)
+}
+
+// formatRangeUnified returns the range from start to stop in unified diff
+// format.
+func formatRangeUnified(start, stop int) string {
+	beginning := start + 1
+	length := stop - start
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", beginning-1)
+	case 1:
+		return fmt.Sprintf("%d", beginning)
+	default:
+		return fmt.Sprintf("%d,%d", beginning, length)
+	}
+}
@@ -0,0 +1,250 @@
+package flatjson
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	lines := make([][]string, 0, 2)
+	for _, filename := range []string{"testdata/a.json", "testdata/b.json"} {
+		data, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		sb := &strings.Builder{}
+		assert.NoError(t, NewFlattener(sb).WriteValues(data))
+		lines = append(lines, SplitLines(sb.String()))
+	}
+	sb := &strings.Builder{}
+	assert.NoError(t, WriteUnifiedDiff(sb, UnifiedDiff{
+		A:        lines[0],
+		B:        lines[1],
+		FromFile: "testdata/a.json",
+		ToFile:   "testdata/b.json",
+		Context:  3,
+	}))
+	assert.Equal(t, strings.Join([]string{
+		"--- testdata/a.json",
+		"+++ testdata/b.json",
+		"@@ -1,5 +1,6 @@ root.menu.disabled",
+		" root = {};",
+		" root.menu = {};",
+		"+root.menu.disabled = true;",
+		" root.menu.id = \"file\";",
+		" root.menu.popup = {};",
+		" root.menu.popup.menuitem = [];",
+		"@@ -9,8 +10,5 @@ root.menu",
+		" root.menu.popup.menuitem[1] = {};",
+		" root.menu.popup.menuitem[1].onclick = \"OpenDoc()\";",
+		" root.menu.popup.menuitem[1].value = \"Open\";",
+		"-root.menu.popup.menuitem[2] = {};",
+		"-root.menu.popup.menuitem[2].onclick = \"CloseDoc()\";",
+		"-root.menu.popup.menuitem[2].value = \"Close\";",
+		"-root.menu.value = \"File\";",
+		"+root.menu.value = \"File menu\";",
+		" ",
+		"",
+	}, "\n"), sb.String())
+}
+
+func TestWriteUnifiedDiffOptions(t *testing.T) {
+	a := []string{"root = {};\n", "root.a = \"File\";\n", "root.b = 1;\n", "root.c = true;\n"}
+	b := []string{"root = {};\n", "root.a = \"File menu\";\n", "root.c = true;\n", "root.d = null;\n"}
+	for i, tc := range []struct {
+		diff     UnifiedDiff
+		expected []string
+	}{
+		{
+			diff: UnifiedDiff{Color: true},
+			expected: []string{
+				"\x1b[1m--- a\x1b[m",
+				"\x1b[1m+++ b\x1b[m",
+				"\x1b[36m@@ -1,4 +1,4 @@\x1b[m root",
+				" root = {};",
+				"\x1b[31m-root.a = \"File\";\x1b[m",
+				"\x1b[31m-root.b = 1;\x1b[m",
+				"\x1b[32m+root.a = \"File menu\";\x1b[m",
+				" root.c = true;",
+				"\x1b[32m+root.d = null;\x1b[m",
+			},
+		},
+		{
+			diff: UnifiedDiff{SideBySide: true, Width: 53},
+			expected: []string{
+				"--- a",
+				"+++ b",
+				"@@ -1,4 +1,4 @@ root",
+				"root = {};                  root = {};",
+				"root.a = \"File\";          | root.a = \"File menu\";",
+				"root.b = 1;               <",
+				"root.c = true;              root.c = true;",
+				"                          > root.d = null;",
+			},
+		},
+		{
+			diff: UnifiedDiff{WordDiff: true},
+			expected: []string{
+				"--- a",
+				"+++ b",
+				"@@ -1,4 +1,4 @@ root",
+				"root = {};",
+				"root.a = \"File{+ menu+}\";",
+				"[-root.b = 1;-]",
+				"root.c = true;",
+				"{+root.d = null;+}",
+			},
+		},
+		{
+			diff: UnifiedDiff{WordDiff: true, Color: true},
+			expected: []string{
+				"\x1b[1m--- a\x1b[m",
+				"\x1b[1m+++ b\x1b[m",
+				"\x1b[36m@@ -1,4 +1,4 @@\x1b[m root",
+				"root = {};",
+				"root.a = \"File\x1b[32m menu\x1b[m\";",
+				"\x1b[31mroot.b = 1;\x1b[m",
+				"root.c = true;",
+				"\x1b[32mroot.d = null;\x1b[m",
+			},
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tc.diff.A = a
+			tc.diff.B = b
+			tc.diff.FromFile = "a"
+			tc.diff.ToFile = "b"
+			tc.diff.Context = 3
+			sb := &strings.Builder{}
+			assert.NoError(t, WriteUnifiedDiff(sb, tc.diff))
+			assert.Equal(t, strings.Join(tc.expected, "\n")+"\n", sb.String())
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	assert.Equal(t, []string(nil), splitWords(""))
+	assert.Equal(t, []string{"root", ".", "a", " ", "=", " ", "\"", "File", " ", "menu", "\"", ";"}, splitWords(`root.a = "File menu";`))
+	assert.Equal(t, []string{"données_1", "€"}, splitWords("données_1€"))
+}
+
+func TestColumn(t *testing.T) {
+	for i, tc := range []struct {
+		line     string
+		width    int
+		pad      bool
+		expected string
+	}{
+		{line: "abc\n", width: 5, pad: true, expected: "abc  "},
+		{line: "abc\n", width: 5, pad: false, expected: "abc"},
+		{line: "abcdef\n", width: 5, pad: true, expected: "abcde"},
+		{line: "日本語\n", width: 6, pad: true, expected: "日本語"},
+		{line: "日本語\n", width: 5, pad: true, expected: "日本 "},
+		{line: "日本語\n", width: 5, pad: false, expected: "日本"},
+		{line: "a😀b\n", width: 5, pad: true, expected: "a😀b "},
+		{line: "e\u0301e\u0301\n", width: 3, pad: true, expected: "e\u0301e\u0301 "},
+		{line: "ｆｕｌｌ\n", width: 4, pad: true, expected: "ｆｕ"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, column(tc.line, tc.width, tc.pad))
+		})
+	}
+}
+
+func TestWriteUnifiedDiffEqual(t *testing.T) {
+	sb := &strings.Builder{}
+	assert.NoError(t, WriteUnifiedDiff(sb, UnifiedDiff{
+		A: []string{"root = 0;\n"},
+		B: []string{"root = 0;\n"},
+	}))
+	assert.Equal(t, "", sb.String())
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Equal(t, []string{"\n"}, SplitLines(""))
+	assert.Equal(t, []string{"a\n"}, SplitLines("a"))
+	assert.Equal(t, []string{"a\n", "\n"}, SplitLines("a\n"))
+	assert.Equal(t, []string{"a\n", "b\n"}, SplitLines("a\nb"))
+	assert.Equal(t, []string{"a\n", "\n", "\n"}, SplitLines("a\n\n"))
+}
+
+func TestCommonAncestorPath(t *testing.T) {
+	for i, tc := range []struct {
+		lines    []string
+		expected string
+	}{
+		{lines: nil, expected: ""},
+		{lines: []string{"root = {};\n"}, expected: "root"},
+		{lines: []string{"root.a.b = 0;\n"}, expected: "root.a.b"},
+		{lines: []string{"root.a.b = 0;\n", "root.a.c = 0;\n"}, expected: "root.a"},
+		{lines: []string{"root.a[1].b = 0;\n", "root.a[1][\"c.d\"] = 0;\n"}, expected: "root.a[1]"},
+		{lines: []string{"root.a[1] = 0;\n", "root.a[2] = 0;\n"}, expected: "root.a"},
+		{lines: []string{"root.a = 0;\n", "other.a = 0;\n"}, expected: ""},
+		{lines: []string{"root.données.a = 0;\n", "root.données.b = 0;\n"}, expected: "root.données"},
+		{lines: []string{"root[\"données\"].a = 0;\n", "root[\"données\"].b = 0;\n"}, expected: "root[\"données\"]"},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			keys := make([]pathKey, 0, len(tc.lines))
+			like := ""
+			for _, line := range tc.lines {
+				if like == "" {
+					like = line
+				}
+				identifier, properties, err := parseAssignmentPath(line)
+				assert.NoError(t, err)
+				keys = append(keys, pathKey{identifier: identifier, properties: properties})
+			}
+			assert.Equal(t, tc.expected, commonAncestorPath(keys, like))
+		})
+	}
+}
+
+func TestLineAssignments(t *testing.T) {
+	lines := SplitLines("x\nroot = {};\nroot.s = `a\nb\nc`;\nroot.t = 1;\n")
+	assert.Equal(t, []lineAssignment{
+		{},
+		{key: pathKey{identifier: "root"}, line: "root = {};\n"},
+		{key: pathKey{identifier: "root", properties: []interface{}{"s"}}, line: "root.s = `a\n"},
+		{key: pathKey{identifier: "root", properties: []interface{}{"s"}}, line: "root.s = `a\n"},
+		{key: pathKey{identifier: "root", properties: []interface{}{"s"}}, line: "root.s = `a\n"},
+		{key: pathKey{identifier: "root", properties: []interface{}{"t"}}, line: "root.t = 1;\n"},
+		{key: pathKey{identifier: "root", properties: []interface{}{"t"}}, line: "root.t = 1;\n"},
+	}, lineAssignments(lines))
+}
+
+func TestHunkPath(t *testing.T) {
+	for i, tc := range []struct {
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			a:        "root = {};\nroot.s = `x\ny`;\nroot.t = 1;\n",
+			b:        "root = {};\nroot.s = `x\nz`;\nroot.t = 2;\n",
+			expected: "root",
+		},
+		{
+			a:        "root = {};\nroot.s = `x\ny\n`;\nroot.t = 1;\n",
+			b:        "root = {};\nroot.s = `x\ny\nz`;\nroot.t = 1;\n",
+			expected: "root.s",
+		},
+		{
+			a:        "root = {};\nroot.a = {};\nroot.a.b = 1;\ninvalid\n",
+			b:        "root = {};\nroot.a = {};\nroot.a.b = 1;\nchanged\n",
+			expected: "root.a.b",
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			a, b := SplitLines(tc.a), SplitLines(tc.b)
+			assert.Equal(t, tc.expected, hunkPath(lineAssignments(a), lineAssignments(b), DiffLines(a, b)))
+		})
+	}
+}
+
+func TestFormatRangeUnified(t *testing.T) {
+	assert.Equal(t, "0,0", formatRangeUnified(0, 0))
+	assert.Equal(t, "1", formatRangeUnified(0, 1))
+	assert.Equal(t, "3,2", formatRangeUnified(2, 4))
+}